@@ -0,0 +1,359 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// CompressSuffix is the suffix of compressed rotated log files
+	CompressSuffix = ".gz"
+
+	// suffix of a file rotated out by size or lines, before it is
+	// renamed to the numbered name in background
+	pendingSuffix = ".rotating"
+)
+
+// archiveJob is a log file rotated out, waiting for background work.
+// Settings of the sink are copied into the job, so that the background
+// work never waits for the lock of the sink.
+type archiveJob struct {
+	// path of the file rotated out, empty if only a sweep is needed
+	path string
+	// path the file was written as, and why it was rotated
+	oldPath string
+	reason  RotateReason
+	// called after the file is archived
+	onRotate RotateHandler
+	// whether the file is compressed
+	compress bool
+
+	// when not empty, path is a pending file rotated out by size or lines,
+	// numbered files of shift are shifted and path becomes shift.1
+	shift string
+	// number of numbered files kept
+	retentions int64
+	// when set, path is rotated by time by every process sharing it, the
+	// one claiming it first archives it
+	claim bool
+	// rotated file expired when rotated by time, it is removed
+	expired string
+	// directory of date directories when named by path template, empty
+	// date directories under it are removed with files
+	root string
+
+	// pattern of every rotated file of the sink
+	pattern string
+	// file being written, it is never swept
+	current string
+	// rotated files older than maxAge are removed
+	maxAge time.Duration
+	// oldest rotated files are removed while total size exceeds maxBytes
+	maxBytes int64
+	// newest keep rotated files are kept when files are named by template,
+	// 0 means no limit
+	keep int64
+}
+
+// sweepLater queues a sweep of rotated files
+func (sink *FileSink) sweepLater() {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	sink.queueArchive(sink.archiveJob(""))
+}
+
+// archiveJob create a job of path with current settings of the sink, it
+// must be called with the lock of sink held
+func (sink *FileSink) archiveJob(path string) archiveJob {
+	job := archiveJob{
+		path:       path,
+		onRotate:   sink.onRotate,
+		compress:   sink.compress,
+		retentions: sink.retentions,
+		pattern:    globQuote(sink.fileName) + ".*",
+		current:    sink.currentFileName,
+		maxAge:     sink.maxAge,
+		maxBytes:   sink.maxBytes,
+	}
+
+	if nil != sink.pathTemplate && sink.timeRotated {
+		job.pattern = sink.pathTemplate.glob(sink.fileName)
+		job.root = sink.pathTemplate.root(sink.fileName)
+	}
+	if nil != sink.nameTemplate {
+		job.pattern = sink.nameTemplate.glob(sink.fileName, sink.timeRotated)
+		job.keep = sink.retentions
+	}
+	return job
+}
+
+// queueArchive queues job for background work, it never blocks. It must be
+// called with the lock of sink held.
+func (sink *FileSink) queueArchive(job archiveJob) {
+	if sink.closed {
+		return
+	}
+
+	sink.archiveJobsLock.Lock()
+	sink.archiveJobs = append(sink.archiveJobs, job)
+	sink.archiveJobsLock.Unlock()
+
+	// wake up archiveDaemon, it is already awake if signal is pending
+	select {
+	case sink.archiveSig <- struct{}{}:
+	default:
+	}
+}
+
+// nextArchive pop the first job queued
+func (sink *FileSink) nextArchive() (job archiveJob, ok bool) {
+	sink.archiveJobsLock.Lock()
+	defer sink.archiveJobsLock.Unlock()
+
+	if 0 == len(sink.archiveJobs) {
+		return job, false
+	}
+	job = sink.archiveJobs[0]
+	sink.archiveJobs = sink.archiveJobs[1:]
+	return job, true
+}
+
+// archiveDaemon run in background as NewFileSink called, it does the work
+// of rotated files one by one in the order they are queued. Jobs queued
+// are done before it exits when sink is closed.
+func (sink *FileSink) archiveDaemon() {
+	defer sink.archiveWg.Done()
+
+	for range sink.archiveSig {
+		for job, ok := sink.nextArchive(); ok; job, ok = sink.nextArchive() {
+			sink.archive(job)
+		}
+	}
+	for job, ok := sink.nextArchive(); ok; job, ok = sink.nextArchive() {
+		sink.archive(job)
+	}
+}
+
+// archive does the background work of a rotated file. Pending files are
+// compressed before they are renamed to the final name, so that numbered
+// files shifted by other processes sharing them are never compressed.
+func (sink *FileSink) archive(job archiveJob) {
+	// rotated files are not renamed or removed while compressing
+	sink.archiveLock.Lock()
+	defer sink.archiveLock.Unlock()
+
+	// the name of the file when it is archived
+	archived := job.path
+	if "" != job.shift {
+		archived = fmt.Sprintf("%s.1", job.shift)
+	}
+
+	if job.claim {
+		claimed := false
+		err := sink.lockArchive(func() (err error) {
+			claimed, err = sink.archiveFlock.claim(job.path)
+			return err
+		})
+		if nil != err {
+			sink.handleError(err)
+		}
+		// archived by other processes
+		if !claimed {
+			return
+		}
+	}
+
+	if "" != job.path && job.compress {
+		if compressed, err := compressFile(job.path); nil != err {
+			sink.handleError(err)
+		} else {
+			job.path = compressed
+			archived += CompressSuffix
+		}
+	}
+
+	if job.path != archived {
+		err := sink.lockArchive(func() error {
+			if "" != job.shift {
+				// rotated files may be compressed, both names are shifted
+				removeArchive(fmt.Sprintf("%s.%d", job.shift, job.retentions))
+				for i := job.retentions - 1; i > 0; i-- {
+					oldName := fmt.Sprintf("%s.%d", job.shift, i)
+					newName := fmt.Sprintf("%s.%d", job.shift, i+1)
+					renameArchive(oldName, newName)
+				}
+			}
+			return os.Rename(job.path, archived)
+		})
+		if nil != err {
+			sink.handleError(err)
+			return
+		}
+		job.path = archived
+	}
+
+	if "" != job.expired {
+		sink.lockArchive(func() error {
+			removeArchive(job.expired)
+			// files of the period rotated on demand
+			if matches, err := filepath.Glob(globQuote(job.expired) + ".*"); nil == err {
+				for _, match := range matches {
+					os.Remove(match)
+				}
+			}
+			if "" != job.root {
+				removeEmptyDirs(filepath.Dir(job.expired), job.root)
+			}
+			return nil
+		})
+	}
+
+	if "" != job.path && nil != job.onRotate {
+		if err := job.onRotate(job.oldPath, job.path, job.reason); nil != err {
+			sink.handleError(err)
+		}
+	}
+
+	if job.maxAge > 0 || job.maxBytes > 0 || job.keep > 0 {
+		if err := sweep(job); nil != err {
+			sink.handleError(err)
+		}
+	}
+}
+
+// lockArchive calls fn with the lock file locked exclusively in shared
+// mode, so that rotated files are renamed by one process at a time
+func (sink *FileSink) lockArchive(fn func() error) error {
+	if nil == sink.archiveFlock {
+		return fn()
+	}
+	if err := sink.archiveFlock.lock(); nil != err {
+		return err
+	}
+	defer sink.archiveFlock.unlock()
+	return fn()
+}
+
+// sweep removes rotated files matching pattern of job, which are older
+// than maxAge, or the oldest ones while total size of the current file and
+// rotated files exceeds maxBytes, or the ones beyond the newest keep.
+// Files missed before, such as the ones rotated before a restart, are
+// removed as well. Pending files are never removed.
+func sweep(job archiveJob) error {
+	matches, err := filepath.Glob(job.pattern)
+	if nil != err {
+		return err
+	}
+
+	// size of the current file counts in total size
+	var total int64
+	if info, err := os.Stat(job.current); nil == err {
+		total = info.Size()
+	}
+
+	rotated := make([]os.FileInfo, 0, len(matches))
+	paths := make(map[os.FileInfo]string, len(matches))
+	for _, path := range matches {
+		if path == job.current || strings.Contains(path, pendingSuffix) {
+			continue
+		}
+		info, err := os.Lstat(path)
+		if nil != err || !info.Mode().IsRegular() {
+			continue
+		}
+		rotated = append(rotated, info)
+		paths[info] = path
+	}
+
+	// newest first, files rotated at the same time are ordered by name
+	sort.Slice(rotated, func(i, j int) bool {
+		if !rotated[i].ModTime().Equal(rotated[j].ModTime()) {
+			return rotated[i].ModTime().After(rotated[j].ModTime())
+		}
+		return paths[rotated[i]] > paths[rotated[j]]
+	})
+
+	var firstErr error
+	expired := time.Now().Add(-job.maxAge)
+	for i, info := range rotated {
+		total += info.Size()
+		if (job.maxAge > 0 && info.ModTime().Before(expired)) || (job.maxBytes > 0 && total > job.maxBytes) || (job.keep > 0 && int64(i) >= job.keep) {
+			if err := os.Remove(paths[info]); nil != err && !os.IsNotExist(err) && nil == firstErr {
+				firstErr = err
+			}
+			if "" != job.root {
+				removeEmptyDirs(filepath.Dir(paths[info]), job.root)
+			}
+		}
+	}
+	return firstErr
+}
+
+// globQuote escapes meta characters of filepath.Match in path
+func globQuote(path string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(path)
+}
+
+// compressFile compress path into path.gz and remove path, it returns the
+// path of compressed file. The compressed file is written to a temporary
+// file first, so that a half written one is never seen.
+func compressFile(path string) (string, error) {
+	src, err := os.Open(path)
+	if nil != err {
+		return "", err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if nil != err {
+		return "", err
+	}
+
+	compressed := path + CompressSuffix
+	tmp := compressed + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if nil != err {
+		return "", err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); nil == err {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); nil == err {
+		err = closeErr
+	}
+	if nil == err {
+		err = os.Rename(tmp, compressed)
+	}
+	if nil != err {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	return compressed, os.Remove(path)
+}
+
+// removeArchive removes a rotated file, compressed or not
+func removeArchive(path string) {
+	os.Remove(path)
+	os.Remove(path + CompressSuffix)
+}
+
+// renameArchive renames a rotated file, compressed or not
+func renameArchive(oldPath string, newPath string) {
+	if _, err := os.Stat(oldPath); nil == err {
+		os.Rename(oldPath, newPath)
+	}
+	if _, err := os.Stat(oldPath + CompressSuffix); nil == err {
+		os.Rename(oldPath+CompressSuffix, newPath+CompressSuffix)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readGzip returns content of a gzip file
+func readGzip(t *testing.T, name string) string {
+	f, err := os.Open(name)
+	if nil != err {
+		t.Fatalf("open %s failed. err: %s", name, err.Error())
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if nil != err {
+		t.Fatalf("read gzip %s failed. err: %s", name, err.Error())
+	}
+	content, err := ioutil.ReadAll(gz)
+	if nil != err {
+		t.Fatalf("read gzip %s failed. err: %s", name, err.Error())
+	}
+	return string(content)
+}
+
+func TestCompressFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "rotated.log")
+	ioutil.WriteFile(name, []byte("rotated lines\n"), 0644)
+
+	compressed, err := compressFile(name)
+	if nil != err || name+CompressSuffix != compressed {
+		t.Fatalf("compress file failed. compressed: %s, err: %v", compressed, err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Error("source file should be removed after compressed")
+	}
+	if content := readGzip(t, compressed); "rotated lines\n" != content {
+		t.Errorf("compressed content wrong. content: %q", content)
+	}
+
+	// shift keeps compressed files
+	renameArchive(name, name+".2")
+	if _, err := os.Stat(name + ".2" + CompressSuffix); nil != err {
+		t.Errorf("compressed file not renamed. err: %s", err.Error())
+	}
+	removeArchive(name + ".2")
+	if _, err := os.Stat(name + ".2" + CompressSuffix); !os.IsNotExist(err) {
+		t.Error("compressed file not removed")
+	}
+}
+
+func TestFileSinkCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "compress.log")
+	sink, err := NewFileSink(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	sink.SetRotateLines(2)
+	sink.SetCompress(true)
+	writer := newSinkWriter(sink)
+
+	writer.Info("first")
+	writer.Info("second")
+	// file is rotated before the third line is written
+	writer.Info("third")
+
+	// wait for logrotate and compression in background
+	compressed := name + ".1" + CompressSuffix
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(compressed); nil == err {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	writer.Close()
+
+	content := readGzip(t, compressed)
+	if 2 != strings.Count(content, "\n") {
+		t.Errorf("rotated file content wrong. content: %q", content)
+	}
+	if _, err := os.Stat(name + ".1"); !os.IsNotExist(err) {
+		t.Error("rotated file should be removed after compressed")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "sweep[1].log")
+	now := time.Now()
+	// current file and rotated files, one hour older each
+	files := []string{name, name + ".1", name + ".2.gz", name + ".3", name + ".4"}
+	for i, file := range files {
+		ioutil.WriteFile(file, []byte("0123456789"), 0644)
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		os.Chtimes(file, modTime, modTime)
+	}
+	// files of other logs are never swept
+	other := path.Join(dir, "sweep[1].logger")
+	ioutil.WriteFile(other, []byte("0123456789"), 0644)
+
+	sink := &FileSink{fileName: name, currentFileName: name, maxAge: 150 * time.Minute}
+	if err := sweep(sink.archiveJob("")); nil != err {
+		t.Fatal(err)
+	}
+	for i, file := range files {
+		if _, err := os.Stat(file); (i < 3) != (nil == err) {
+			t.Errorf("max age sweep wrong. file: %s, err: %v", file, err)
+		}
+	}
+
+	sink.maxAge = 0
+	sink.maxBytes = 25
+	if err := sweep(sink.archiveJob("")); nil != err {
+		t.Fatal(err)
+	}
+	for i, file := range files {
+		if _, err := os.Stat(file); (i < 2) != (nil == err) {
+			t.Errorf("max bytes sweep wrong. file: %s, err: %v", file, err)
+		}
+	}
+	if _, err := os.Stat(other); nil != err {
+		t.Errorf("file of other logs swept. err: %s", err.Error())
+	}
+}
+
+func TestFileSinkSweepOnStartup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// rotated before restart
+	name := path.Join(dir, "startup.log")
+	expired := name + ".2016-03-09"
+	ioutil.WriteFile(expired, []byte("expired\n"), 0644)
+	modTime := time.Now().Add(-30 * PeriodDay)
+	os.Chtimes(expired, modTime, modTime)
+
+	writer, err := NewBaseFileLogger(name, true)
+	if nil != err {
+		t.Fatal(err)
+	}
+	writer.SetMaxAge(7 * PeriodDay)
+	writer.Close()
+
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Errorf("expired file not swept. err: %v", err)
+	}
+	if _, err := os.Stat(periodFileName(name, timeCache.Now(), PeriodDay)); nil != err {
+		t.Errorf("current file swept. err: %v", err)
+	}
+}
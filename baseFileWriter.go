@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,20 +28,25 @@ const (
 	// DefaultRotateLines is default lines when lines base logrotate needed
 	DefaultRotateLines = 2000000 // 2 million
 
-	// DefaultLogRetentionCount is the default days of logs to be keeped
+	// DefaultLogRetentionCount is the default periods of logs to be keeped
 	DefaultLogRetentionCount = 7
 )
 
-// baseFileWriter defines a writer for single file.
-// It suppurts partially write while formatting message, logging level filtering,
-// logrotate, user defined hook for every logging action, change configuration
-// on the fly and logging with colors.
-type baseFileWriter struct {
+// number of files rotated out by size or lines in the process, it names
+// pending files
+var pendings uint64
+
+// FileSink defines a sink for single file.
+// It suppurts partially write while formatting message, logrotate, change
+// configuration on the fly and logging with colors.
+type FileSink struct {
 	// configuration about file
 	// full path of the file, the same as configuration
 	fileName string
-	// current file name of the writer, may be changed with logrotate
+	// current file name of the sink, may be changed with logrotate
 	currentFileName string
+	// options opening files
+	options FileOptions
 	// the file object
 	file *os.File
 
@@ -48,17 +54,9 @@ type baseFileWriter struct {
 	blog *BLog
 
 	// close sign, default false
-	// set this tag true if writer is closed
+	// set this tag true if sink is closed
 	closed bool
 
-	// configuration about user defined logging hook
-	// actual hook instance
-	hook Hook
-	// hook is called when message level exceed level of logging action
-	hookLevel LevelType
-	// it determines whether hook is called async, default true
-	hookAsync bool
-
 	// configuration about logrotate
 	// exclusive lock use in logrotate
 	lock *sync.RWMutex
@@ -67,6 +65,14 @@ type baseFileWriter struct {
 	// sign of time base logrotate, default false
 	// set this tag true if logrotate in time base mode
 	timeRotated bool
+	// period of time base logrotate, default PeriodDay
+	period time.Duration
+	// names files rotated by time with date directories when set, instead
+	// of suffix of date, default nil
+	pathTemplate *pathTemplate
+	// sign of keeping a symlink at fileName pointing to the current file
+	// when time rotated, default false
+	symlinked bool
 	// signal send when time base rotate needed
 	timeRotateSig chan bool
 
@@ -88,17 +94,65 @@ type baseFileWriter struct {
 	rotateSize int64
 	// total size written after last size && line logrotate
 	currentSize int64
-	// channel used to sum up sizes written from last logrotate
-	logSizeChan chan int
+	// names files rotated by size or lines when set, instead of shifting
+	// numbered files, default nil
+	nameTemplate *nameTemplate
 
 	// number of logs retention when time base logrotate or size base logrotate
 	retentions int64
+	// rotated files older than maxAge are removed, default 0 means no limit
+	maxAge time.Duration
+	// oldest rotated files are removed while total size of logs exceeds
+	// maxBytes, default 0 means no limit
+	maxBytes int64
+
+	// configuration about rotated files
+	// sign of compressing rotated files with gzip, default false
+	compress bool
+	// rotated files waiting for background work
+	archiveJobs []archiveJob
+	// exclusive lock of archiveJobs
+	archiveJobsLock *sync.Mutex
+	// signal send when archiveJobs queued
+	archiveSig chan struct{}
+	// wait for background work of rotated files when closed
+	archiveWg *sync.WaitGroup
+	// exclusive lock while renaming, removing or compressing rotated files
+	archiveLock *sync.Mutex
+
+	// lock files of shared mode, nil if the file is not shared by processes.
+	// flock is locked while writing and archiveFlock by background work,
+	// locks of the lock file opened once never exclude each other.
+	flock        *fileLock
+	archiveFlock *fileLock
+
+	// called after a rotated file is archived, default nil
+	onRotate RotateHandler
 
 	// sign decided logging with colors or not, default false
 	colored bool
+
+	// interval of flushing in background, default DefaultFlushInterval
+	flushInterval time.Duration
+	flushTicker   *time.Ticker
+	// when the file is synced to disk, default SyncNever
+	syncPolicy SyncPolicy
+	// interval of syncing with SyncInterval, and time of the last sync
+	syncInterval time.Duration
+	lastSync     time.Time
+
+	// handler of errors met in background
+	errorHandler ErrorHandler
+	// errors met with lock held, they are passed to errorHandler after the
+	// lock is released, so that the handler may log through the same writer
+	pendingErrors []error
+	// sign of errors being passed to errorHandler
+	reporting bool
+	// exclusive lock of errorHandler, pendingErrors and reporting
+	errorsLock *sync.Mutex
 }
 
-// NewBaseFileWriter initialize a base file writer
+// NewBaseFileWriter initialize the default writer as a base file writer
 func NewBaseFileWriter(fileName string, timeRotated bool) (err error) {
 	singltonLock.Lock()
 	defer singltonLock.Unlock()
@@ -107,7 +161,7 @@ func NewBaseFileWriter(fileName string, timeRotated bool) (err error) {
 		return ErrAlreadyInit
 	}
 
-	baseFileWriter, err := newBaseFileWriter(fileName, timeRotated)
+	baseFileWriter, err := NewBaseFileLogger(fileName, timeRotated)
 	if nil != err {
 		return err
 	}
@@ -116,485 +170,1020 @@ func NewBaseFileWriter(fileName string, timeRotated bool) (err error) {
 	return err
 }
 
-// newbaseFileWriter create a single file writer instance and return the poionter
-// of it. When any errors happened during creation, a null writer and appropriate
+// NewBaseFileLoggerWithOptions create an independent writer like
+// NewBaseFileLogger, files are opened with options
+func NewBaseFileLoggerWithOptions(fileName string, timeRotated bool, options FileOptions) (Writer, error) {
+	sink, err := NewFileSinkWithOptions(fileName, timeRotated, options)
+	if nil != err {
+		return nil, err
+	}
+	return newSinkWriter(sink), nil
+}
+
+// NewBaseFileLogger create an independent writer logging every level into
+// a single file
+// fileName must be an absolute path to the destination log file
+// timeRotated determine if it will do time base logrotate
+func NewBaseFileLogger(fileName string, timeRotated bool) (Writer, error) {
+	sink, err := NewFileSink(fileName, timeRotated)
+	if nil != err {
+		return nil, err
+	}
+	return newSinkWriter(sink), nil
+}
+
+// NewFileSink create a single file sink instance and return the poionter
+// of it. When any errors happened during creation, a null sink and appropriate
 // will be returned.
 // fileName must be an absolute path to the destination log file
-// rotate determine if it will logrotate
-func newBaseFileWriter(fileName string, timeRotated bool) (fileWriter *baseFileWriter, err error) {
-	fileWriter = new(baseFileWriter)
-	fileWriter.fileName = fileName
+// timeRotated determine if it will do time base logrotate
+func NewFileSink(fileName string, timeRotated bool) (sink *FileSink, err error) {
+	return NewFileSinkWithOptions(fileName, timeRotated, DefaultFileOptions())
+}
+
+// NewFileSinkWithOptions create a single file sink like NewFileSink, files
+// are opened with options, such as mode, creating missing directories and
+// owner
+func NewFileSinkWithOptions(fileName string, timeRotated bool, options FileOptions) (sink *FileSink, err error) {
+	sink = new(FileSink)
+	sink.fileName = fileName
+	sink.options = options.withDefaults()
+	sink.period = PeriodDay
 	// open file target file
 	if timeRotated {
-		fileName = fmt.Sprintf("%s.%s", fileName, timeCache.Date())
+		now := timeCache.Now()
+		if sink.options.Shared {
+			now = time.Now()
+		}
+		fileName = periodFileName(fileName, now, sink.period)
 	}
-	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, os.FileMode(0644))
-	fileWriter.file = file
-	fileWriter.currentFileName = fileName
+	file, err := openFile(fileName, sink.options)
+	sink.file = file
+	sink.currentFileName = fileName
 	if nil != err {
 		return nil, err
 	}
-	fileWriter.blog = NewBLog(file)
+	if sink.options.Shared {
+		if sink.flock, err = openFileLock(lockFileName(sink.fileName), sink.options.Mode); nil != err {
+			file.Close()
+			return nil, err
+		}
+		if sink.archiveFlock, err = openFileLock(lockFileName(sink.fileName), sink.options.Mode); nil != err {
+			sink.flock.close()
+			file.Close()
+			return nil, err
+		}
+	}
+	sink.blog = NewBLog(file)
 
-	fileWriter.closed = false
+	sink.closed = false
 
 	// about logrotate
-	fileWriter.lock = new(sync.RWMutex)
-	fileWriter.timeRotated = timeRotated
-	fileWriter.timeRotateSig = make(chan bool)
-	fileWriter.sizeRotateSig = make(chan bool)
-	fileWriter.logSizeChan = make(chan int, 8192)
+	sink.lock = new(sync.RWMutex)
+	sink.timeRotated = timeRotated
+	sink.timeRotateSig = make(chan bool)
+	sink.sizeRotateSig = make(chan bool)
+
+	sink.lineRotated = false
+	sink.rotateSize = DefaultRotateSize
+	// size of the file appended counts
+	sink.currentSize = 0
+	if info, err := file.Stat(); nil == err {
+		sink.currentSize = info.Size()
+	}
+
+	sink.sizeRotated = false
+	sink.rotateLines = DefaultRotateLines
+	sink.currentLines = 0
+	sink.retentions = DefaultLogRetentionCount
 
-	fileWriter.lineRotated = false
-	fileWriter.rotateSize = DefaultRotateSize
-	fileWriter.currentSize = 0
+	sink.compress = false
+	sink.archiveJobsLock = new(sync.Mutex)
+	sink.archiveSig = make(chan struct{}, 1)
+	sink.archiveWg = new(sync.WaitGroup)
+	sink.archiveLock = new(sync.Mutex)
 
-	fileWriter.sizeRotated = false
-	fileWriter.rotateLines = DefaultRotateLines
-	fileWriter.currentLines = 0
-	fileWriter.retentions = DefaultLogRetentionCount
+	sink.colored = false
 
-	fileWriter.colored = false
+	sink.flushInterval = DefaultFlushInterval
+	sink.flushTicker = time.NewTicker(sink.flushInterval)
+	sink.syncPolicy = SyncNever
 
-	// log hook
-	fileWriter.hook = nil
-	fileWriter.hookLevel = DEBUG
-	fileWriter.hookAsync = true
+	sink.errorsLock = new(sync.Mutex)
 
-	go fileWriter.daemon()
+	sink.archiveWg.Add(1)
+	go sink.archiveDaemon()
+	go sink.daemon()
 
-	return fileWriter, nil
+	registerFileSink(sink)
+
+	return sink, nil
 }
 
-// daemon run in background as NewbaseFileWriter called.
-// It flushes writer buffer every 1 second.
+// daemon run in background as NewFileSink called.
+// It flushes writer buffer every flush interval.
+// It reopens the file moved or deleted by others.
 // It decides whether a time base when logrotate is needed.
-// It sums up lines && sizes already written. Alse it does the lines &&
-// size base logrotate
-func (writer *baseFileWriter) daemon() {
+// It syncs the file every sync interval if needed.
+func (sink *FileSink) daemon() {
 	// tick every seconds
 	// time base logrotate
 	t := time.Tick(1 * time.Second)
-	// tick every second
 	// auto flush writer buffer
-	f := time.Tick(1 * time.Second)
+	defer sink.flushTicker.Stop()
 
 DaemonLoop:
 	for {
 		select {
-		case <-f:
-			if writer.Closed() {
+		case <-sink.flushTicker.C:
+			if sink.Closed() {
 				break DaemonLoop
 			}
 
-			writer.blog.flush()
+			if err := sink.Flush(); nil != err {
+				sink.handleError(err)
+			}
 		case <-t:
-			if writer.Closed() {
+			if sink.Closed() {
 				break DaemonLoop
 			}
 
-			if writer.timeRotated {
-				// if fileName not equal to currentFileName, it needs a time base logrotate
-				if fileName := fmt.Sprintf("%s.%s", writer.fileName, timeCache.Date()); writer.currentFileName != fileName {
-					writer.resetFile()
-					writer.currentFileName = fileName
-
-					// when it needs to expire logs
-					if writer.retentions > 0 {
-						// format the expired log file name
-						date := timeCache.Now().Add(time.Duration(-24*(writer.retentions+1)) * time.Hour).Format(DateFormat)
-						expiredFileName := fmt.Sprintf("%s.%s", writer.fileName, date)
-						// check if expired log exists
-						if _, err := os.Stat(expiredFileName); nil == err {
-							os.Remove(expiredFileName)
-						}
-					}
+			if sink.moved() {
+				if err := sink.Reopen(); nil != err {
+					sink.handleError(err)
 				}
 			}
 
-		// analyse lines && size written
-		// do lines && size base logrotate
-		case size := <-writer.logSizeChan:
-			if writer.Closed() {
-				break DaemonLoop
+			if sink.TimeRotated() {
+				// it is tried again next tick when reopen failed
+				if err := sink.timeRotate(sink.now()); nil != err {
+					sink.handleError(err)
+				}
 			}
 
-			if !writer.sizeRotated && !writer.lineRotated {
-				continue
+			if err := sink.periodicSync(time.Now()); nil != err {
+				sink.handleError(err)
 			}
 
-			// TODO have any better solution?
-			// use func to ensure writer.lock will be released
-			writer.lock.Lock()
-			writer.currentSize += int64(size)
-			writer.currentLines++
-			writer.lock.Unlock()
-
-			if (writer.sizeRotated && writer.currentSize >= writer.rotateSize) || (writer.lineRotated && writer.currentLines >= writer.rotateLines) {
-				// need lines && size base logrotate
-				var oldName, newName string
-				oldName = fmt.Sprintf("%s.%d", writer.currentFileName, writer.retentions)
-				// check if expired log exists
-				if _, err := os.Stat(oldName); os.IsNotExist(err) {
-					os.Remove(oldName)
-				}
-				if writer.retentions > 0 {
+			// errors met while other errors were reported
+			sink.reportErrors()
+		}
+	}
+}
 
-					for i := writer.retentions - 1; i > 0; i-- {
-						oldName = fmt.Sprintf("%s.%d", writer.currentFileName, i)
-						newName = fmt.Sprintf("%s.%d", writer.currentFileName, i+1)
-						os.Rename(oldName, newName)
-					}
-					os.Rename(writer.currentFileName, oldName)
+// periodicSync flushes and syncs the file if sync interval has passed
+// since the last sync with SyncInterval
+func (sink *FileSink) periodicSync(now time.Time) error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
 
-					writer.resetFile()
-				}
-			}
+	if sink.closed || SyncInterval != sink.syncPolicy || now.Sub(sink.lastSync) < sink.syncInterval {
+		return nil
+	}
+	sink.lastSync = now
+	if err := sink.blog.flush(); nil != err {
+		return err
+	}
+	return sink.file.Sync()
+}
+
+// timeRotate does time base logrotate if needed, other processes wait
+// while it is done in shared mode
+func (sink *FileSink) timeRotate(now time.Time) error {
+	defer sink.reportErrors()
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	if sink.closed {
+		return nil
+	}
+	if nil != sink.flock {
+		if err := sink.flock.lock(); nil != err {
+			return err
 		}
+		defer sink.flock.unlock()
 	}
+	return sink.rotateByTime(now)
 }
 
-// resetFile reset current writing file
-func (writer *baseFileWriter) resetFile() {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
+// rotateByTime switches to the file of the period of now if it is not the
+// current one, it must be called with lock held. The old file is archived
+// and the expired one is removed in background.
+func (sink *FileSink) rotateByTime(now time.Time) error {
+	fileName := sink.periodFileName(now)
+	if sink.currentFileName == fileName {
+		return nil
+	}
 
-	fileName := writer.fileName
-	if writer.timeRotated {
-		fileName = fmt.Sprintf("%s.%s", fileName, timeCache.Date())
+	rotatedFileName := sink.currentFileName
+	if err := sink.open(fileName); nil != err {
+		return err
 	}
-	file, _ := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, os.FileMode(0644))
-	writer.blog.resetFile(file)
-	writer.file.Close()
-	writer.file = file
 
-	writer.currentSize = 0
-	writer.currentLines = 0
+	job := sink.archiveJob(rotatedFileName)
+	job.oldPath = rotatedFileName
+	job.reason = RotateByTime
+	// every process sharing the file rotates it, only one archives it
+	job.claim = nil != sink.flock
+	// when it needs to expire logs
+	if sink.retentions > 0 {
+		expired := periodsAgo(periodStart(now, sink.period), sink.period, sink.retentions+1)
+		job.expired = sink.periodFileName(expired)
+	}
+	sink.queueArchive(job)
+	return nil
 }
 
-// write writes pure message with specific level
-func (writer *baseFileWriter) write(level LevelType, args ...interface{}) {
-	var size = 0
+// resetFile reset current writing file, current file is kept when the new
+// one can not be opened
+func (sink *FileSink) resetFile() error {
+	defer sink.reportErrors()
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
 
-	if writer.closed {
-		return
+	if sink.closed {
+		return ErrSinkClosed
 	}
+	return sink.reset()
+}
 
-	defer func() {
-		// 异步调用log hook
-		if nil != writer.hook && !(level < writer.hookLevel) {
-			if writer.hookAsync {
-				go func(level LevelType, args ...interface{}) {
-					writer.hook.Fire(level, args...)
-				}(level, args...)
-
-			} else {
-				writer.hook.Fire(level, args...)
-			}
-		}
+// reset reopen current writing file, it must be called with lock held.
+// It never switches to the file of another period, which is left to
+// rotateByTime so that the file of the last period is archived.
+func (sink *FileSink) reset() error {
+	return sink.open(sink.currentFileName)
+}
+
+// now return time deciding the file written. In shared mode it is never
+// taken from cache, which is refreshed at different time by processes, so
+// that no process switches back to the file of the last period.
+func (sink *FileSink) now() time.Time {
+	if nil != sink.flock {
+		return time.Now()
+	}
+	return timeCache.Now()
+}
 
-		// logrotate
-		if writer.sizeRotated || writer.lineRotated {
-			writer.logSizeChan <- size
+// expectedFileName return name of the file written at now, it must be
+// called with lock held
+func (sink *FileSink) expectedFileName(now time.Time) string {
+	if sink.timeRotated {
+		return sink.periodFileName(now)
+	}
+	return sink.fileName
+}
+
+// periodFileName return name of the file written in the period of t when
+// time rotated, it must be called with lock held
+func (sink *FileSink) periodFileName(t time.Time) string {
+	if nil != sink.pathTemplate {
+		return sink.pathTemplate.render(sink.fileName, periodStart(t, sink.period))
+	}
+	return periodFileName(sink.fileName, t, sink.period)
+}
+
+// open switches current writing file to fileName, it must be called with
+// lock held
+func (sink *FileSink) open(fileName string) error {
+	options := sink.options
+	// date directories are created as needed
+	if nil != sink.pathTemplate {
+		options.Mkdir = true
+	}
+	file, err := openFile(fileName, options)
+	if nil != err {
+		return err
+	}
+	if err = sink.blog.resetFile(file); nil != err {
+		sink.queueError(err)
+	}
+	// logs of the old file are flushed by resetFile
+	if SyncNever != sink.syncPolicy {
+		if err = sink.file.Sync(); nil != err {
+			sink.queueError(err)
 		}
-	}()
+	}
+	sink.file.Close()
+	sink.file = file
+	sink.currentFileName = fileName
+
+	// size of the file appended counts
+	sink.currentSize = 0
+	if info, err := file.Stat(); nil == err {
+		sink.currentSize = info.Size()
+	}
+	sink.currentLines = 0
 
-	size = writer.blog.write(level, args...)
+	sink.linkCurrent()
+	return nil
 }
 
-// write formats message with specific level and write it
-func (writer *baseFileWriter) writef(level LevelType, format string, args ...interface{}) {
-	// 格式化构造message
-	// 边解析边输出
-	// 使用 % 作占位符
+// moved checks whether the file written was moved or deleted by others,
+// such as logrotate of the system, by comparing device and inode of the
+// file opened and the one at its name
+func (sink *FileSink) moved() bool {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.isMoved()
+}
 
-	// 统计日志size
-	var size = 0
+// isMoved is moved with lock held
+func (sink *FileSink) isMoved() bool {
+	opened, err := sink.file.Stat()
+	if nil != err {
+		return false
+	}
+	current, err := os.Stat(sink.currentFileName)
+	return nil != err || !os.SameFile(opened, current)
+}
 
-	if writer.closed {
+// linkCurrent points symlink at fileName to the current file if needed,
+// it must be called with lock held
+func (sink *FileSink) linkCurrent() {
+	if !sink.symlinked || sink.fileName == sink.currentFileName {
 		return
 	}
+	if err := updateSymlink(sink.fileName, sink.currentFileName); nil != err {
+		sink.queueError(err)
+	}
+}
 
-	defer func() {
-		// 异步调用log hook
-		if nil != writer.hook && !(level < writer.hookLevel) {
-			if writer.hookAsync {
-				go func(level LevelType, format string, args ...interface{}) {
-					writer.hook.Fire(level, fmt.Sprintf(format, args...))
-				}(level, format, args...)
-
-			} else {
-				writer.hook.Fire(level, fmt.Sprintf(format, args...))
-			}
-		}
+// Reopen reopen the file, current file is kept when it can not be opened.
+// The file of the current period is switched to by time base logrotate.
+func (sink *FileSink) Reopen() error {
+	return sink.resetFile()
+}
 
-		// logrotate
-		if writer.sizeRotated || writer.lineRotated {
-			writer.logSizeChan <- size
-		}
-	}()
+// SetErrorHandler set handler of errors met in background, such as
+// flushing and logrotate
+func (sink *FileSink) SetErrorHandler(handler ErrorHandler) {
+	sink.errorsLock.Lock()
+	defer sink.errorsLock.Unlock()
+	sink.errorHandler = handler
+}
+
+// handleError pass err to the error handler if set, it must be called
+// without lock held
+func (sink *FileSink) handleError(err error) {
+	sink.errorsLock.Lock()
+	handler := sink.errorHandler
+	sink.errorsLock.Unlock()
 
-	size = writer.blog.writef(level, format, args...)
+	if nil != handler {
+		handler(err)
+	}
 }
 
-// Closed get writer status
-func (writer *baseFileWriter) Closed() bool {
-	writer.lock.RLock()
-	defer writer.lock.RUnlock()
-	return writer.closed
+// queueError keeps err met with lock held, it is passed to the error
+// handler by reportErrors after the lock is released
+func (sink *FileSink) queueError(err error) {
+	sink.errorsLock.Lock()
+	defer sink.errorsLock.Unlock()
+	sink.pendingErrors = append(sink.pendingErrors, err)
 }
 
-// Close close file writer
-func (writer *baseFileWriter) Close() {
-	if writer.Closed() {
+// reportErrors pass errors queued to the error handler, it must be called
+// without lock held. Errors met while the handler logs through the same
+// writer are left to the next call, instead of reported recursively.
+func (sink *FileSink) reportErrors() {
+	sink.errorsLock.Lock()
+	if sink.reporting || 0 == len(sink.pendingErrors) {
+		sink.errorsLock.Unlock()
 		return
 	}
+	errs := sink.pendingErrors
+	sink.pendingErrors = nil
+	sink.reporting = true
+	handler := sink.errorHandler
+	sink.errorsLock.Unlock()
 
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
+	defer func() {
+		sink.errorsLock.Lock()
+		sink.reporting = false
+		sink.errorsLock.Unlock()
+	}()
+	if nil == handler {
+		return
+	}
+	for _, err := range errs {
+		handler(err)
+	}
+}
 
-	writer.closed = true
-	writer.blog.flush()
-	writer.blog.Close()
-	writer.blog = nil
-	writer.file.Close()
-	close(writer.logSizeChan)
-	close(writer.timeRotateSig)
-	close(writer.sizeRotateSig)
+// Closed get sink status
+func (sink *FileSink) Closed() bool {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.closed
 }
 
-// TimeRotated get timeRotated
-func (writer *baseFileWriter) TimeRotated() bool {
-	writer.lock.RLock()
-	defer writer.lock.RUnlock()
-	return writer.timeRotated
+// WriteEntry writes an entry into file. When size or line base logrotate
+// is needed, lines and size are summed up while writing, and file is
+// rotated before the line exceeding the threshold is written, so that
+// lines are never split into two files.
+func (sink *FileSink) WriteEntry(entry *Entry) error {
+	defer sink.reportErrors()
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	if sink.closed {
+		return ErrSinkClosed
+	}
+
+	if nil != sink.flock {
+		return sink.writeShared(entry)
+	}
+
+	if !sink.sizeRotated && !sink.lineRotated {
+		_, err := sink.blog.write(entry)
+		return err
+	}
+
+	if !sink.sizeRotated {
+		if reason, due := sink.rotateDue(0); due {
+			if err := sink.rotate(reason); nil != err {
+				sink.queueError(err)
+			}
+		}
+		size, err := sink.blog.write(entry)
+		sink.currentSize += int64(size)
+		sink.currentLines++
+		return err
+	}
+
+	// line is encoded first to know its size
+	line := sink.blog.encode(entry)
+	if reason, due := sink.rotateDue(len(line)); due {
+		if err := sink.rotate(reason); nil != err {
+			sink.queueError(err)
+		}
+	}
+	size, err := sink.blog.writeBytes(line)
+	sink.currentSize += int64(size)
+	sink.currentLines++
+	return err
 }
 
-// SetTimeRotated toggle time base logrotate on the fly
-func (writer *baseFileWriter) SetTimeRotated(timeRotated bool) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-	writer.timeRotated = timeRotated
+// writeShared writes an entry into file shared by other processes, it must
+// be called with lock held. Every line is written and flushed at once under
+// shared lock of the lock file, logrotate is done under exclusive lock, so
+// that no process writes while the file is rotated. Size is taken from the
+// file written by every process, lines are counted by every process on its
+// own. File rotated by other processes is reopened before writing.
+func (sink *FileSink) writeShared(entry *Entry) error {
+	if err := sink.flock.rlock(); nil != err {
+		return err
+	}
+	defer sink.flock.unlock()
+
+	line := sink.blog.encode(entry)
+	if sink.staled(sink.now(), len(line)) {
+		// shared lock is released while converted into exclusive lock,
+		// file may be rotated by others meanwhile
+		if err := sink.flock.lock(); nil != err {
+			return err
+		}
+		sink.rotateShared(sink.now(), len(line))
+	}
+
+	size, err := sink.blog.writeBytes(line)
+	if nil == err {
+		err = sink.blog.flush()
+	}
+	if nil == err && SyncOnFlush == sink.syncPolicy {
+		err = sink.file.Sync()
+	}
+	sink.currentSize += int64(size)
+	sink.currentLines++
+	return err
 }
 
-// Retentions get log retention days
-func (writer *baseFileWriter) Retentions() int64 {
-	writer.lock.RLock()
-	defer writer.lock.RUnlock()
-	return writer.retentions
+// staled checks whether file shared must be rotated or reopened before a
+// line of size is written at now, it must be called with lock held
+func (sink *FileSink) staled(now time.Time, size int) bool {
+	if sink.currentFileName != sink.expectedFileName(now) || sink.isMoved() {
+		return true
+	}
+	if info, err := sink.file.Stat(); nil == err {
+		sink.currentSize = info.Size()
+	}
+	_, due := sink.rotateDue(size)
+	return due
 }
 
-// SetExpiredDays set how many days of logs will keep
-func (writer *baseFileWriter) SetRetentions(retentions int64) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-	if retentions < 1 {
-		return
+// rotateShared rotates or reopens file shared before a line of size is
+// written at now, it must be called with lock and exclusive lock of the
+// lock file held
+func (sink *FileSink) rotateShared(now time.Time, size int) {
+	if sink.timeRotated {
+		if err := sink.rotateByTime(now); nil != err {
+			sink.queueError(err)
+		}
+	}
+	if sink.isMoved() {
+		if err := sink.reset(); nil != err {
+			sink.queueError(err)
+		}
+	}
+	if info, err := sink.file.Stat(); nil == err {
+		sink.currentSize = info.Size()
+	}
+	if reason, due := sink.rotateDue(size); due {
+		if err := sink.rotate(reason); nil != err {
+			sink.queueError(err)
+		}
 	}
-	writer.retentions = retentions
 }
 
-// RotateSize get log rotate size
-func (writer *baseFileWriter) RotateSize() int64 {
-	writer.lock.RLock()
-	defer writer.lock.RUnlock()
-	return writer.rotateSize
+// rotateDue checks whether file must be rotated before a line of size is
+// written, it must be called with lock held
+func (sink *FileSink) rotateDue(size int) (RotateReason, bool) {
+	if sink.sizeRotated && sink.currentSize > 0 && sink.currentSize+int64(size) > sink.rotateSize {
+		return RotateBySize, true
+	}
+	if sink.lineRotated && sink.currentLines >= sink.rotateLines {
+		return RotateByLines, true
+	}
+	return RotateByTime, false
 }
 
-// SetRotateSize set size when logroatate
-func (writer *baseFileWriter) SetRotateSize(rotateSize int64) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-	if rotateSize > 0 {
-		writer.sizeRotated = true
-		writer.rotateSize = rotateSize
-	} else {
-		writer.sizeRotated = false
+// rotate does size && line base logrotate, it must be called with lock
+// held. Current file is renamed to a pending name and a new one is opened
+// at once, numbered files are shifted in background.
+func (sink *FileSink) rotate(reason RotateReason) error {
+	if nil != sink.nameTemplate {
+		return sink.rotateByTemplate(reason)
+	}
+
+	// pending files of sinks and processes sharing the file never collide
+	pendingFileName := fmt.Sprintf("%s%s.%d.%d", sink.currentFileName, pendingSuffix, os.Getpid(), atomic.AddUint64(&pendings, 1))
+	if err := os.Rename(sink.currentFileName, pendingFileName); nil != err {
+		return err
 	}
+
+	shift := sink.currentFileName
+	err := sink.reset()
+	if nil != err {
+		// keep writing into the pending file
+		sink.currentFileName = pendingFileName
+	}
+
+	job := sink.archiveJob(pendingFileName)
+	job.shift = shift
+	job.oldPath = shift
+	job.reason = reason
+	sink.queueArchive(job)
+	return err
 }
 
-// RotateLines get log rotate lines
-func (writer *baseFileWriter) RotateLines() int {
-	writer.lock.RLock()
-	defer writer.lock.RUnlock()
-	return writer.rotateLines
+// rotateByTemplate does size && line base logrotate with name template,
+// it must be called with lock held. Current file is renamed to a name not
+// used yet, files rotated before are never renamed.
+func (sink *FileSink) rotateByTemplate(reason RotateReason) error {
+	oldFileName := sink.currentFileName
+	rotatedFileName := sink.nameTemplate.next(oldFileName, timeCache.Now())
+	if err := os.Rename(sink.currentFileName, rotatedFileName); nil != err {
+		return err
+	}
+
+	err := sink.reset()
+	if nil != err {
+		// keep writing into the rotated file
+		sink.currentFileName = rotatedFileName
+	}
+
+	job := sink.archiveJob(rotatedFileName)
+	job.oldPath = oldFileName
+	job.reason = reason
+	sink.queueArchive(job)
+	return err
 }
 
-// SetRotateLines set line number when logrotate
-func (writer *baseFileWriter) SetRotateLines(rotateLines int) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-	if rotateLines > 0 {
-		writer.lineRotated = true
-		writer.rotateLines = rotateLines
-	} else {
-		writer.lineRotated = false
+// Rotate rotates the file at once if anything has been written into it,
+// such as at the start of a deployment. Files rotated by size or lines are
+// shifted or named by template as usual, the file of the current period is
+// shifted like xxx.2006-01-02.1 when time rotated.
+func (sink *FileSink) Rotate() error {
+	defer sink.reportErrors()
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	if sink.closed {
+		return ErrSinkClosed
+	}
+	if nil != sink.flock {
+		if err := sink.flock.lock(); nil != err {
+			return err
+		}
+		defer sink.flock.unlock()
+	}
+
+	if err := sink.blog.flush(); nil != err {
+		return err
 	}
+	if info, err := sink.file.Stat(); nil != err || 0 == info.Size() {
+		return err
+	}
+	return sink.rotate(RotateByRequest)
 }
 
-// Colored get whether it is log with colored
-func (writer *baseFileWriter) Colored() bool {
-	writer.lock.RLock()
-	defer writer.lock.RUnlock()
-	return writer.colored
+// Flush flush logs to disk, the file is synced with SyncOnFlush
+func (sink *FileSink) Flush() error {
+	if err := sink.blog.flush(); nil != err {
+		return err
+	}
+
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	if SyncOnFlush == sink.syncPolicy && !sink.closed {
+		return sink.file.Sync()
+	}
+	return nil
 }
 
-// SetColored set logging color
-func (writer *baseFileWriter) SetColored(colored bool) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-	if colored == writer.colored {
-		return
+// sync flushes logs and syncs the file to disk whatever the sync policy is
+func (sink *FileSink) sync() error {
+	if err := sink.blog.flush(); nil != err {
+		return err
 	}
 
-	writer.colored = colored
-	initPrefix(colored)
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	if sink.closed {
+		return nil
+	}
+	return sink.file.Sync()
 }
 
-// Level get log level
-func (writer *baseFileWriter) Level() LevelType {
-	writer.lock.RLock()
-	defer writer.lock.RUnlock()
-	return writer.blog.Level()
+// Close close file sink
+func (sink *FileSink) Close() error {
+	if sink.Closed() {
+		return nil
+	}
+
+	unregisterFileSink(sink)
+
+	sink.lock.Lock()
+	sink.closed = true
+	err := sink.blog.flush()
+	if SyncNever != sink.syncPolicy {
+		if syncErr := sink.file.Sync(); nil == err {
+			err = syncErr
+		}
+	}
+	sink.blog.Close()
+	close(sink.timeRotateSig)
+	close(sink.sizeRotateSig)
+	close(sink.archiveSig)
+	if closeErr := sink.file.Close(); nil == err {
+		err = closeErr
+	}
+	sink.lock.Unlock()
+
+	// wait for background work of rotated files
+	sink.archiveWg.Wait()
+	if nil != sink.flock {
+		sink.flock.close()
+		sink.archiveFlock.close()
+	}
+	return err
 }
 
-// SetLevel set logging level threshold
-func (writer *baseFileWriter) SetLevel(level LevelType) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-	writer.blog.SetLevel(level)
+// TimeRotated get timeRotated
+func (sink *FileSink) TimeRotated() bool {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.timeRotated
 }
 
-// SetHook set hook for the base file writer
-func (writer *baseFileWriter) SetHook(hook Hook) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-	writer.hook = hook
+// SetTimeRotated toggle time base logrotate on the fly
+func (sink *FileSink) SetTimeRotated(timeRotated bool) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.timeRotated = timeRotated
 }
 
-// SetHookAsync set hook async for base file writer
-func (writer *baseFileWriter) SetHookAsync(async bool) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-	writer.hookAsync = async
+// Symlink get whether a symlink to the current file is kept
+func (sink *FileSink) Symlink() bool {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.symlinked
 }
 
-// SetHookLevel set when hook will be called
-func (writer *baseFileWriter) SetHookLevel(level LevelType) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-	writer.hookLevel = level
+// SetSymlink set whether a symlink is kept at the file name configured,
+// pointing to the current file when time rotated, so that it can be
+// followed by tail -F. The symlink is updated atomically on every
+// logrotate.
+func (sink *FileSink) SetSymlink(symlinked bool) {
+	defer sink.reportErrors()
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.symlinked = symlinked
+	sink.linkCurrent()
 }
 
-// flush flush logs to disk
-func (writer *baseFileWriter) flush() {
-	writer.blog.flush()
+// RotatePeriod get period of time base logrotate
+func (sink *FileSink) RotatePeriod() time.Duration {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.period
 }
 
-// Trace trace
-func (writer *baseFileWriter) Trace(args ...interface{}) {
-	if nil == writer.blog || TRACE < writer.blog.Level() {
+// SetRotatePeriod set period of time base logrotate on the fly, such as
+// PeriodHour. Rotated files are named with suffix in layout of the finest
+// unit of period, retentions are counted in periods. The file opened is
+// switched at once, it is removed if nothing has been written.
+func (sink *FileSink) SetRotatePeriod(period time.Duration) {
+	if period < time.Second {
 		return
 	}
 
-	writer.write(TRACE, args...)
+	defer sink.reportErrors()
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.period = period
+	if sink.closed || !sink.timeRotated {
+		return
+	}
+	if err := sink.switchFile(); nil != err {
+		sink.queueError(err)
+	}
 }
 
-// Tracef tracef
-func (writer *baseFileWriter) Tracef(format string, args ...interface{}) {
-	if nil == writer.blog || TRACE < writer.blog.Level() {
-		return
+// PathTemplate get path template of files rotated by time, empty if files
+// are named with suffix of date
+func (sink *FileSink) PathTemplate() string {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	if nil == sink.pathTemplate {
+		return ""
 	}
+	return sink.pathTemplate.template
+}
 
-	writer.writef(TRACE, format, args...)
+// SetPathTemplate set path template of files rotated by time, such as
+// "logs/%d{2006/01/02}/%f", so that files are stored in directories
+// partitioned by date, relative templates are resolved against the
+// directory of the file. Directories are created as needed, empty ones are
+// removed with expired files. The file opened is switched at once, it is
+// removed if nothing has been written. Empty template names files with
+// suffix of date as default.
+func (sink *FileSink) SetPathTemplate(template string) error {
+	var pathTemplate *pathTemplate
+	if "" != template {
+		var err error
+		if pathTemplate, err = newPathTemplate(template); nil != err {
+			return err
+		}
+	}
+
+	defer sink.reportErrors()
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	if sink.closed {
+		return ErrSinkClosed
+	}
+	sink.pathTemplate = pathTemplate
+	if !sink.timeRotated {
+		return nil
+	}
+
+	return sink.switchFile()
 }
 
-// Debug debug
-func (writer *baseFileWriter) Debug(args ...interface{}) {
-	if nil == writer.blog || DEBUG < writer.blog.Level() {
-		return
+// switchFile switches to the file expected when naming of files rotated by
+// time is changed, the old file is removed if nothing has been written. It
+// must be called with lock held.
+func (sink *FileSink) switchFile() error {
+	if nil != sink.flock {
+		if err := sink.flock.lock(); nil != err {
+			return err
+		}
+		defer sink.flock.unlock()
 	}
 
-	writer.write(DEBUG, args...)
+	oldFileName := sink.currentFileName
+	if err := sink.open(sink.expectedFileName(sink.now())); nil != err {
+		return err
+	}
+	if info, err := os.Stat(oldFileName); nil == err && 0 == info.Size() && oldFileName != sink.currentFileName {
+		os.Remove(oldFileName)
+	}
+	return nil
 }
 
-// Debugf debugf
-func (writer *baseFileWriter) Debugf(format string, args ...interface{}) {
-	if nil == writer.blog || DEBUG < writer.blog.Level() {
+// Retentions get log retention periods
+func (sink *FileSink) Retentions() int64 {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.retentions
+}
+
+// SetRetentions set how many periods of logs will keep
+func (sink *FileSink) SetRetentions(retentions int64) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if retentions < 1 {
 		return
 	}
+	sink.retentions = retentions
+}
 
-	writer.writef(DEBUG, format, args...)
+// MaxAge get max age of rotated files
+func (sink *FileSink) MaxAge() time.Duration {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.maxAge
 }
 
-// Info info
-func (writer *baseFileWriter) Info(args ...interface{}) {
-	if nil == writer.blog || INFO < writer.blog.Level() {
-		return
+// SetMaxAge set max age of rotated files, 0 means no limit. Rotated files
+// are swept at once and after every logrotate.
+func (sink *FileSink) SetMaxAge(maxAge time.Duration) {
+	sink.lock.Lock()
+	if maxAge < 0 {
+		maxAge = 0
 	}
+	sink.maxAge = maxAge
+	sink.lock.Unlock()
 
-	writer.write(INFO, args...)
+	sink.sweepLater()
 }
 
-// Infof infof
-func (writer *baseFileWriter) Infof(format string, args ...interface{}) {
-	if nil == writer.blog || INFO < writer.blog.Level() {
-		return
+// MaxBytes get max total size of logs
+func (sink *FileSink) MaxBytes() int64 {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.maxBytes
+}
+
+// SetMaxBytes set max total size of the current file and rotated files,
+// 0 means no limit. Rotated files are swept at once and after every
+// logrotate.
+func (sink *FileSink) SetMaxBytes(maxBytes int64) {
+	sink.lock.Lock()
+	if maxBytes < 0 {
+		maxBytes = 0
 	}
+	sink.maxBytes = maxBytes
+	sink.lock.Unlock()
 
-	writer.writef(INFO, format, args...)
+	sink.sweepLater()
 }
 
-// Warn warn
-func (writer *baseFileWriter) Warn(args ...interface{}) {
-	if nil == writer.blog || WARNING < writer.blog.Level() {
-		return
+// NameTemplate get name template of files rotated by size or lines, empty
+// if numbered files are shifted
+func (sink *FileSink) NameTemplate() string {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	if nil == sink.nameTemplate {
+		return ""
 	}
+	return sink.nameTemplate.template
+}
 
-	writer.write(WARNING, args...)
+// SetNameTemplate set name template of files rotated by size or lines,
+// such as DefaultNameTemplate. Rotated files are never renamed again, the
+// newest retentions of them are kept. Empty template shifts numbered files
+// xxx.1, xxx.2 as default.
+func (sink *FileSink) SetNameTemplate(template string) error {
+	var nameTemplate *nameTemplate
+	if "" != template {
+		var err error
+		if nameTemplate, err = newNameTemplate(template); nil != err {
+			return err
+		}
+	}
+
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.nameTemplate = nameTemplate
+	return nil
 }
 
-// Warnf warn
-func (writer *baseFileWriter) Warnf(format string, args ...interface{}) {
-	if nil == writer.blog || WARNING < writer.blog.Level() {
-		return
+// SetRotateHandler set handler called in background after a file is
+// rotated and archived, such as for uploading it
+func (sink *FileSink) SetRotateHandler(handler RotateHandler) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.onRotate = handler
+}
+
+// RotateSize get log rotate size
+func (sink *FileSink) RotateSize() int64 {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.rotateSize
+}
+
+// SetRotateSize set size when logroatate
+func (sink *FileSink) SetRotateSize(rotateSize int64) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if rotateSize > 0 {
+		sink.sizeRotated = true
+		sink.rotateSize = rotateSize
+	} else {
+		sink.sizeRotated = false
 	}
+}
 
-	writer.writef(WARNING, format, args...)
+// RotateLines get log rotate lines
+func (sink *FileSink) RotateLines() int {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.rotateLines
 }
 
-// Error error
-func (writer *baseFileWriter) Error(args ...interface{}) {
-	if nil == writer.blog || ERROR < writer.blog.Level() {
-		return
+// SetRotateLines set line number when logrotate
+func (sink *FileSink) SetRotateLines(rotateLines int) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if rotateLines > 0 {
+		sink.lineRotated = true
+		sink.rotateLines = rotateLines
+	} else {
+		sink.lineRotated = false
 	}
+}
+
+// Compress get whether rotated files are compressed
+func (sink *FileSink) Compress() bool {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.compress
+}
+
+// SetCompress set whether rotated files are compressed with gzip in
+// background, compressed files are named with CompressSuffix
+func (sink *FileSink) SetCompress(compress bool) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.compress = compress
+}
 
-	writer.write(ERROR, args...)
+// FlushInterval get interval of flushing in background
+func (sink *FileSink) FlushInterval() time.Duration {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.flushInterval
 }
 
-// Errorf errorf
-func (writer *baseFileWriter) Errorf(format string, args ...interface{}) {
-	if nil == writer.blog || ERROR < writer.blog.Level() {
+// SetFlushInterval set interval of flushing in background on the fly, it
+// is ignored if not positive
+func (sink *FileSink) SetFlushInterval(interval time.Duration) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if interval <= 0 {
 		return
 	}
+	sink.flushInterval = interval
+	sink.flushTicker.Reset(interval)
+}
 
-	writer.writef(ERROR, format, args...)
+// SyncPolicy get when the file is synced to disk
+func (sink *FileSink) SyncPolicy() SyncPolicy {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.syncPolicy
 }
 
-// Critical critical
-func (writer *baseFileWriter) Critical(args ...interface{}) {
-	if nil == writer.blog || CRITICAL < writer.blog.Level() {
+// SetSyncPolicy set when the file is synced to disk with fsync, interval
+// is used by SyncInterval only. The file is always synced before rotated
+// or closed unless SyncNever.
+func (sink *FileSink) SetSyncPolicy(policy SyncPolicy, interval time.Duration) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if SyncInterval == policy && interval <= 0 {
 		return
 	}
+	sink.syncPolicy = policy
+	sink.syncInterval = interval
+	sink.lastSync = time.Now()
+}
 
-	writer.write(CRITICAL, args...)
+// Colored get whether it is log with colored
+func (sink *FileSink) Colored() bool {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.colored
 }
 
-// Criticalf criticalf
-func (writer *baseFileWriter) Criticalf(format string, args ...interface{}) {
-	if nil == writer.blog || CRITICAL < writer.blog.Level() {
+// SetColored set logging color
+func (sink *FileSink) SetColored(colored bool) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if colored == sink.colored {
 		return
 	}
 
-	writer.writef(CRITICAL, format, args...)
+	sink.colored = colored
+	sink.blog.SetColored(colored)
+}
+
+// SetEncoder set encoder formatting log lines
+func (sink *FileSink) SetEncoder(encoder Encoder) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.blog.SetEncoder(encoder)
 }
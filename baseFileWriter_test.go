@@ -3,7 +3,12 @@
 package blog4go
 
 import (
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -123,3 +128,46 @@ func TestBaseFileWriterBasicOperation(t *testing.T) {
 	blog.Debug("Debug", 1)
 	blog.Debugf("%s", "Debug")
 }
+
+func TestFileSinkRotateOnWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "size.log")
+	sink, err := NewFileSink(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	writer := newSinkWriter(sink)
+
+	// every line is of the same size
+	entry := newEntry(INFO, nil, false, "", []interface{}{"0123456789"})
+	line := sink.blog.encode(entry)
+	sink.SetRotateSize(int64(3*len(line) + len(line)/2))
+	sink.SetRetentions(10)
+
+	for i := 0; i < 10; i++ {
+		writer.Info("0123456789")
+	}
+	writer.Close()
+
+	// 3 lines in every file, newest file is current one
+	for fileName, expected := range map[string]int{name: 1, name + ".1": 3, name + ".2": 3, name + ".3": 3} {
+		content, err := ioutil.ReadFile(fileName)
+		if nil != err {
+			t.Fatalf("read %s failed. err: %s", fileName, err.Error())
+		}
+		if lines := strings.Count(string(content), "\n"); expected != lines {
+			t.Errorf("lines of %s wrong. expected: %d, got: %d", fileName, expected, lines)
+		}
+		if int64(len(content)) > sink.RotateSize() {
+			t.Errorf("size of %s exceeds. size: %d", fileName, len(content))
+		}
+	}
+	if matches, _ := filepath.Glob(name + pendingSuffix + "*"); 0 != len(matches) {
+		t.Errorf("pending files left. files: %v", matches)
+	}
+}
@@ -7,12 +7,14 @@ package blog4go
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -25,7 +27,7 @@ const (
 )
 
 var (
-	// blog is the singleton instance use for blog.write/writef
+	// blog is the default instance used by package level functions
 	blog Writer
 
 	// global mutex log used for singlton
@@ -41,17 +43,28 @@ var (
 
 // Writer interface is a common definition of any writers in this package.
 // Any struct implements Writer interface must implement functions below.
+// Writers outside this package are built by implementing Sink and wrapping
+// it with NewSinkLogger.
 // Close is used for close the writer and free any elements if needed.
 // write is an internal function that write pure message with specific
-// logging level.
+// logging level and fields.
 // writef is an internal function that formatting message with specific
-// logging level. Placeholders in the format string will be replaced with
-// args given.
+// logging level and fields. Placeholders in the format string will be
+// replaced with args given.
+// Both write and writef drop messages below the logging level threshold,
+// and capture caller of the message if needed. They must be called
+// directly by the logging functions to capture the right caller.
+// writeEntry is an internal function that write an entry already built
+// and filtered by another writer, it returns the entry as written.
 // Both write and writef may have an asynchronous call of user defined
 // function before write and writef function end..
 type Writer interface {
 	// Close do anything end before program end
 	Close()
+	// Reopen reopen files written, such as after moved by logrotate
+	Reopen() error
+	// Rotate rotate files written at once
+	Rotate() error
 
 	// SetLevel set logging level threshold
 	SetLevel(level LevelType)
@@ -59,8 +72,9 @@ type Writer interface {
 	Level() LevelType
 
 	// write/writef functions with different levels
-	write(level LevelType, args ...interface{})
-	writef(level LevelType, format string, args ...interface{})
+	write(level LevelType, fields Fields, args ...interface{})
+	writef(level LevelType, fields Fields, format string, args ...interface{})
+	writeEntry(entry *Entry) *Entry
 	Debug(args ...interface{})
 	Debugf(format string, args ...interface{})
 	Trace(args ...interface{})
@@ -73,26 +87,71 @@ type Writer interface {
 	Errorf(format string, args ...interface{})
 	Critical(args ...interface{})
 	Criticalf(format string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalf(format string, args ...interface{})
+	Panic(args ...interface{})
+	Panicf(format string, args ...interface{})
+
+	// derive a writer attaching structured fields to every message
+	WithFields(fields Fields) Writer
+	With(pairs ...interface{}) Writer
 
 	// flush log to disk
 	flush()
 
 	// hook
 	SetHook(hook Hook)
+	SetEntryHook(hook EntryHook)
 	SetHookLevel(level LevelType)
 	SetHookAsync(async bool)
+	SetHookQueue(size int, workers int)
+	SetHookQueuePolicy(policy HookQueuePolicy)
+	HookDropped() uint64
 
 	// logrotate
 	SetTimeRotated(timeRotated bool)
 	TimeRotated() bool
+	SetRotatePeriod(period time.Duration)
+	RotatePeriod() time.Duration
 	SetRotateSize(rotateSize int64)
 	RotateSize() int64
 	SetRotateLines(rotateLines int)
 	RotateLines() int
 	SetRetentions(retentions int64)
 	Retentions() int64
+	SetMaxAge(maxAge time.Duration)
+	MaxAge() time.Duration
+	SetMaxBytes(maxBytes int64)
+	MaxBytes() int64
 	SetColored(colored bool)
 	Colored() bool
+
+	// encoder of log lines
+	SetEncoder(encoder Encoder)
+
+	// flushing and syncing
+	SetFlushLevel(level LevelType)
+	FlushLevel() LevelType
+	SetFlushInterval(interval time.Duration)
+	FlushInterval() time.Duration
+	SetSyncPolicy(policy SyncPolicy, interval time.Duration)
+	SyncPolicy() SyncPolicy
+
+	// errors met while writing
+	SetErrorHandler(handler ErrorHandler)
+	SetFallback(policy FallbackPolicy)
+	Errors() uint64
+
+	// name of the writer, written by %c of PatternEncoder
+	SetName(name string)
+	Name() string
+
+	// caller of logging actions
+	SetReportCaller(reportCaller bool)
+	ReportCaller() bool
+	SetCallerLevel(level LevelType)
+	CallerLevel() LevelType
+	reportCaller(level LevelType) bool
 }
 
 func init() {
@@ -100,7 +159,8 @@ func init() {
 	DefaultBufferSize = os.Getpagesize()
 }
 
-// NewWriterFromConfigAsFile initialize a writer according to given config file
+// NewWriterFromConfigAsFile initialize the default writer according to given
+// config file
 // configFile must be the path to the config file
 func NewWriterFromConfigAsFile(configFile string) (err error) {
 	singltonLock.Lock()
@@ -109,14 +169,28 @@ func NewWriterFromConfigAsFile(configFile string) (err error) {
 		return ErrAlreadyInit
 	}
 
+	writer, err := NewLoggerFromConfigAsFile(configFile)
+	if nil != err {
+		return err
+	}
+
+	blog = writer
+	return
+}
+
+// NewLoggerFromConfigAsFile create an independent writer according to given
+// config file, the default writer used by package level functions is not
+// affected.
+// configFile must be the path to the config file
+func NewLoggerFromConfigAsFile(configFile string) (Writer, error) {
 	// read config from file
 	config, err := readConfig(configFile)
 	if nil != err {
-		return
+		return nil, err
 	}
 
 	if err = config.valid(); nil != err {
-		return
+		return nil, err
 	}
 
 	multiWriter := new(MultiWriter)
@@ -127,115 +201,167 @@ func NewWriterFromConfigAsFile(configFile string) (err error) {
 	}
 
 	multiWriter.closed = false
-	multiWriter.writers = make(map[LevelType]Writer)
+	multiWriter.writers = make(map[LevelType][]Writer)
+	multiWriter.hookPool = newHookPool()
+	multiWriter.flushLevel = LevelType(-1)
+	multiWriter.flushInterval = DefaultFlushInterval
 
 	for _, filter := range config.Filters {
-		var rotate = false
-		var timeRotate = false
-		var isSocket = false
-		var isConsole = false
-
-		var f *os.File
-		var blog *BLog
-		var fileLock *sync.RWMutex
-
-		// get file path
-		var filePath string
-		if (file{}) != filter.File {
-			// file do not need logrotate
-			filePath = filter.File.Path
-			rotate = false
-
-			f, err = os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, os.FileMode(0644))
-			if nil != err {
-				return err
-			}
-			blog = NewBLog(f)
-			fileLock = new(sync.RWMutex)
-		} else if (rotateFile{}) != filter.RotateFile {
-			// file need logrotate
-			filePath = filter.RotateFile.Path
-			rotate = true
-			timeRotate = TypeTimeBaseRotate == filter.RotateFile.Type
-
-			fileName := filePath
-			if timeRotate {
-				fileName = fmt.Sprintf("%s.%s", fileName, timeCache.Date())
-			}
-			f, err = os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, os.FileMode(0644))
-			if nil != err {
-				return err
-			}
-			blog = NewBLog(f)
-			fileLock = new(sync.RWMutex)
-		} else if (socket{}) != filter.Socket {
-			isSocket = true
-		} else {
-			// use console writer as default
-			isConsole = true
-		}
-
-		levels := strings.Split(filter.Levels, ",")
-		for _, levelStr := range levels {
+		var levels []LevelType
+		for _, levelStr := range strings.Split(filter.Levels, ",") {
 			var level LevelType
 			if level = LevelFromString(levelStr); !level.valid() {
-				return ErrInvalidLevel
+				multiWriter.Close()
+				return nil, ErrInvalidLevel
 			}
+			levels = append(levels, level)
+		}
 
-			if isConsole {
-				// console writer
-				writer, err := newConsoleWriter(filter.Console.Redirect)
-				if nil != err {
-					return err
-				}
+		encoder, err := filter.encoder()
+		if nil != err {
+			multiWriter.Close()
+			return nil, err
+		}
 
-				multiWriter.writers[level] = writer
-				continue
-			}
+		// every filter owns exactly one writer, it is shared by all levels
+		// of the filter so that messages of these levels never interleave
+		writer, err := newFilterWriter(filter)
+		if nil != err {
+			multiWriter.Close()
+			return nil, err
+		}
+		writer.SetEncoder(encoder)
+		writer.SetColored(filter.Colored)
+		// caller conversions of pattern need caller
+		pattern, ok := encoder.(*PatternEncoder)
+		writer.SetReportCaller(filter.Caller || (ok && pattern.caller))
+		if "" != filter.CallerLevel {
+			writer.SetCallerLevel(LevelFromString(filter.CallerLevel))
+		}
+		fallback, _ := FallbackFromString(filter.Fallback)
+		writer.SetFallback(fallback)
+
+		// flushing and syncing
+		if "" != filter.FlushInterval {
+			interval, _ := time.ParseDuration(filter.FlushInterval)
+			writer.SetFlushInterval(interval)
+		}
+		if "" != filter.FlushLevel {
+			writer.SetFlushLevel(LevelFromString(filter.FlushLevel))
+		}
+		if "" != filter.Sync {
+			policy, interval, _ := SyncFromString(filter.Sync)
+			writer.SetSyncPolicy(policy, interval)
+		}
+
+		multiWriter.addWriter(writer, levels...)
+	}
+
+	return multiWriter, nil
+}
+
+// newFilterWriter creates the writer described by a filter of config file
+func newFilterWriter(filter filter) (Writer, error) {
+	if (file{}) != filter.File {
+		// file do not need logrotate
+		options, err := filter.File.options()
+		if nil != err {
+			return nil, err
+		}
+		return NewBaseFileLoggerWithOptions(filter.File.Path, false, options)
+	}
+
+	if (rotateFile{}) != filter.RotateFile {
+		// file need logrotate
+		rotateType := filter.RotateFile.Type
+		if TypeTimeBaseRotate != rotateType && TypeSizeBaseRotate != rotateType {
+			return nil, ErrInvalidRotateType
+		}
+
+		options, err := filter.RotateFile.options()
+		if nil != err {
+			return nil, err
+		}
+		// date directories of path template may not exist yet
+		if "" != filter.RotateFile.PathTemplate {
+			options.Mkdir = true
+		}
 
-			if isSocket {
-				// socket writer
-				writer, err := newSocketWriter(filter.Socket.Network, filter.Socket.Address)
+		sink, err := NewFileSinkWithOptions(filter.RotateFile.Path, TypeTimeBaseRotate == rotateType, options)
+		if nil != err {
+			return nil, err
+		}
+
+		// set logrotate strategy
+		if TypeTimeBaseRotate == rotateType {
+			if "" != filter.RotateFile.Period {
+				period, err := ParsePeriod(filter.RotateFile.Period)
 				if nil != err {
-					return err
+					sink.Close()
+					return nil, err
 				}
-
-				multiWriter.writers[level] = writer
-				continue
+				sink.SetRotatePeriod(period)
 			}
-
-			// init a base file writer
-			writer, err := newBaseFileWriter(filePath, timeRotate)
-			if nil != err {
-				return err
+			sink.SetTimeRotated(true)
+			if err := sink.SetPathTemplate(filter.RotateFile.PathTemplate); nil != err {
+				sink.Close()
+				return nil, err
+			}
+			sink.SetRetentions(filter.RotateFile.Retentions)
+			sink.SetSymlink(filter.RotateFile.Symlink)
+		} else {
+			if err := sink.SetNameTemplate(filter.RotateFile.NameTemplate); nil != err {
+				sink.Close()
+				return nil, err
 			}
+			sink.SetRotateSize(filter.RotateFile.RotateSize)
+			sink.SetRotateLines(filter.RotateFile.RotateLines)
+			sink.SetRetentions(filter.RotateFile.Retentions)
+		}
+		sink.SetCompress(filter.RotateFile.Compress)
+		if "" != filter.RotateFile.OnRotate {
+			sink.SetRotateHandler(CommandRotateHandler(filter.RotateFile.OnRotate))
+		}
 
-			if rotate {
-				// set logrotate strategy
-				if TypeTimeBaseRotate == filter.RotateFile.Type {
-					writer.SetTimeRotated(true)
-					writer.SetRetentions(filter.RotateFile.Retentions)
-				} else if TypeSizeBaseRotate == filter.RotateFile.Type {
-					writer.SetRotateSize(filter.RotateFile.RotateSize)
-					writer.SetRotateLines(filter.RotateFile.RotateLines)
-					writer.SetRetentions(filter.RotateFile.Retentions)
-				} else {
-					return ErrInvalidRotateType
-				}
+		// set retention by age and total size
+		maxAge, err := parseAge(filter.RotateFile.MaxAge)
+		if nil != err {
+			sink.Close()
+			return nil, err
+		}
+		if maxAge > 0 {
+			sink.SetMaxAge(maxAge)
+		}
+		if filter.RotateFile.MaxBytes > 0 {
+			sink.SetMaxBytes(filter.RotateFile.MaxBytes)
+		}
+
+		// every deployment starts a fresh file
+		if filter.RotateFile.RotateOnStartup {
+			if err := sink.Rotate(); nil != err {
+				sink.Close()
+				return nil, err
 			}
+		}
+		return newSinkWriter(sink), nil
+	}
 
-			writer.file = f
-			writer.blog = blog
-			writer.lock = fileLock
+	if (socket{}) != filter.Socket {
+		// socket writer
+		return NewSocketLogger(filter.Socket.Network, filter.Socket.Address)
+	}
 
-			// set color
-			multiWriter.SetColored(filter.Colored)
-			multiWriter.writers[level] = writer
+	if nil != filter.Sink {
+		// sink registered by RegisterSink
+		sink, err := newSink(filter.Sink.Type, filter.Sink.attrs())
+		if nil != err {
+			return nil, err
 		}
+		return NewSinkLogger(sink), nil
 	}
 
-	blog = multiWriter
-	return
+	// use console writer as default
+	return NewConsoleLogger(filter.Console.Redirect)
 }
 
 // BLog struct is a threadsafe log writer inherit bufio.Writer
@@ -253,6 +379,11 @@ type BLog struct {
 	// exclusive lock while calling write function of bufio.Writer
 	lock *sync.Mutex
 
+	// encoder formats every message into a log line
+	encoder Encoder
+	// sign of writing levels in colors with TextEncoder
+	colored bool
+
 	// closed tag
 	closed bool
 }
@@ -265,36 +396,81 @@ func NewBLog(in io.Writer) (blog *BLog) {
 	blog.level = TRACE
 	blog.lock = new(sync.Mutex)
 	blog.closed = false
+	blog.encoder = DefaultEncoder
 
 	blog.writer = bufio.NewWriterSize(in, DefaultBufferSize)
 	return
 }
 
-// write writes pure message with specific level
-func (blog *BLog) write(level LevelType, args ...interface{}) int {
+// write writes an entry with the encoder, it returns number of bytes written
+// and the error met by the buffer.
+// Bytes written are kept in Encoded of the entry if asked for, otherwise
+// they are written to the buffer while encoding.
+func (blog *BLog) write(entry *Entry) (size int, err error) {
 	blog.lock.Lock()
 	defer blog.lock.Unlock()
 
-	// 统计日志size
-	var size = 0
-	format := fmt.Sprint(args...)
+	if !entry.KeepEncoded() {
+		size = blog.lineEncoder().Encode(blog.writer, entry)
+	} else {
+		buffer := new(bytes.Buffer)
+		blog.lineEncoder().Encode(buffer, entry)
+		entry.Encoded = buffer.Bytes()
+		size, _ = blog.writer.Write(entry.Encoded)
+	}
+	return size, blog.checkError()
+}
 
-	blog.writer.Write(timeCache.Format())
-	blog.writer.WriteString(level.prefix())
-	blog.writer.WriteString(format)
-	blog.writer.WriteByte(EOL)
+// lineEncoder return the encoder formatting log lines, TextEncoder writes
+// levels in colors if colored. It must be called with lock held.
+func (blog *BLog) lineEncoder() Encoder {
+	if _, ok := blog.encoder.(TextEncoder); ok && blog.colored {
+		return TextEncoder{Colored: true}
+	}
+	return blog.encoder
+}
 
-	size = len(timeCache.Format()) + len(level.prefix()) + len(format) + 1
-	return size
+// encode formats entry into a log line without writing it, the line is
+// kept in entry if needed
+func (blog *BLog) encode(entry *Entry) []byte {
+	blog.lock.Lock()
+	encoder := blog.lineEncoder()
+	blog.lock.Unlock()
+
+	buffer := new(bytes.Buffer)
+	encoder.Encode(buffer, entry)
+	if entry.KeepEncoded() {
+		entry.Encoded = buffer.Bytes()
+	}
+	return buffer.Bytes()
+}
+
+// writeBytes writes a log line encoded already
+func (blog *BLog) writeBytes(line []byte) (size int, err error) {
+	blog.lock.Lock()
+	defer blog.lock.Unlock()
+
+	size, _ = blog.writer.Write(line)
+	return size, blog.checkError()
 }
 
-// write formats message with specific level and write it
-func (blog *BLog) writef(level LevelType, format string, args ...interface{}) int {
+// checkError return error of bufio.Writer, it must be called with lock held
+func (blog *BLog) checkError() error {
+	// bufio.Writer keeps the first error met, writing nothing returns it
+	_, err := blog.writer.Write(nil)
+	if nil != err {
+		// drop the broken buffer so that later writes may succeed again
+		blog.writer.Reset(blog.in)
+	}
+	return err
+}
+
+// writeFormat writes message formatted with format and args to w, it
+// returns number of bytes written
+func writeFormat(w Buffer, format string, args ...interface{}) int {
 	// 格式化构造message
 	// 边解析边输出
 	// 使用 % 作占位符
-	blog.lock.Lock()
-	defer blog.lock.Unlock()
 
 	// 统计日志size
 	var size = 0
@@ -310,11 +486,6 @@ func (blog *BLog) writef(level LevelType, format string, args ...interface{}) in
 	var last int
 	var s int
 
-	blog.writer.Write(timeCache.Format())
-	blog.writer.WriteString(level.prefix())
-
-	size += len(timeCache.Format()) + len(level.prefix())
-
 	for i, v := range format {
 		if tag {
 			switch v {
@@ -323,7 +494,7 @@ func (blog *BLog) writef(level LevelType, format string, args ...interface{}) in
 					escape = false
 				}
 
-				s, _ = blog.writer.WriteString(fmt.Sprintf(format[tagPos:i+1], args[n]))
+				s, _ = w.WriteString(fmt.Sprintf(format[tagPos:i+1], args[n]))
 				size += s
 				n++
 				last = i + 1
@@ -331,7 +502,7 @@ func (blog *BLog) writef(level LevelType, format string, args ...interface{}) in
 			//转义符
 			case ESCAPE:
 				if escape {
-					blog.writer.WriteByte(ESCAPE)
+					w.WriteByte(ESCAPE)
 					size++
 				}
 				escape = !escape
@@ -345,21 +516,20 @@ func (blog *BLog) writef(level LevelType, format string, args ...interface{}) in
 			if PLACEHOLDER == format[i] && !escape {
 				tag = true
 				tagPos = i
-				s, _ = blog.writer.WriteString(format[last:i])
+				s, _ = w.WriteString(format[last:i])
 				size += s
 				escape = false
 			}
 		}
 	}
-	blog.writer.WriteString(format[last:])
-	blog.writer.WriteByte(EOL)
+	s, _ = w.WriteString(format[last:])
+	size += s
 
-	size += len(format[last:]) + 1
 	return size
 }
 
 // Flush flush buffer to disk
-func (blog *BLog) flush() {
+func (blog *BLog) flush() (err error) {
 	blog.lock.Lock()
 	defer blog.lock.Unlock()
 
@@ -367,7 +537,11 @@ func (blog *BLog) flush() {
 		return
 	}
 
-	blog.writer.Flush()
+	if err = blog.writer.Flush(); nil != err {
+		// drop the broken buffer so that later writes may succeed again
+		blog.writer.Reset(blog.in)
+	}
+	return
 }
 
 // Close close file writer
@@ -400,12 +574,36 @@ func (blog *BLog) SetLevel(level LevelType) *BLog {
 	return blog
 }
 
+// Encoder return the encoder formatting log lines
+func (blog *BLog) Encoder() Encoder {
+	blog.lock.Lock()
+	defer blog.lock.Unlock()
+	return blog.encoder
+}
+
+// SetEncoder set the encoder formatting log lines
+func (blog *BLog) SetEncoder(encoder Encoder) *BLog {
+	blog.lock.Lock()
+	defer blog.lock.Unlock()
+	blog.encoder = encoder
+	return blog
+}
+
+// SetColored set whether levels are written in colors with TextEncoder
+func (blog *BLog) SetColored(colored bool) *BLog {
+	blog.lock.Lock()
+	defer blog.lock.Unlock()
+	blog.colored = colored
+	return blog
+}
+
 // resetFile resets file descriptor of the writer with specific file name
 func (blog *BLog) resetFile(in io.Writer) (err error) {
 	blog.lock.Lock()
 	defer blog.lock.Unlock()
 
-	blog.writer.Flush()
+	// buffered logs belong to the old file
+	err = blog.writer.Flush()
 
 	blog.in = in
 	blog.writer.Reset(in)
@@ -413,6 +611,28 @@ func (blog *BLog) resetFile(in io.Writer) (err error) {
 	return
 }
 
+// Default return the default writer used by package level functions, nil
+// if it is not initialized
+func Default() Writer {
+	singltonLock.Lock()
+	defer singltonLock.Unlock()
+	return blog
+}
+
+// SetDefault replace the default writer used by package level functions
+// with writer, for example one created by NewFileLogger. The replaced
+// writer is not closed, it is returned so that caller can close it.
+// SetDefault is not synchronized with logging functions, so it should be
+// called before logging starts.
+func SetDefault(writer Writer) (replaced Writer) {
+	singltonLock.Lock()
+	defer singltonLock.Unlock()
+
+	replaced = blog
+	blog = writer
+	return
+}
+
 // SetBufferSize set bufio buffer size in bytes
 func SetBufferSize(size int) {
 	DefaultBufferSize = size
@@ -433,6 +653,26 @@ func SetHook(hook Hook) {
 	blog.SetHook(hook)
 }
 
+// SetEntryHook set hook receiving the entry of logging action
+func SetEntryHook(hook EntryHook) {
+	blog.SetEntryHook(hook)
+}
+
+// SetHookQueue set queue size and number of workers delivering async hooks
+func SetHookQueue(size int, workers int) {
+	blog.SetHookQueue(size, workers)
+}
+
+// SetHookQueuePolicy set what to do when queue of async hooks is full
+func SetHookQueuePolicy(policy HookQueuePolicy) {
+	blog.SetHookQueuePolicy(policy)
+}
+
+// HookDropped return number of async hook events dropped
+func HookDropped() uint64 {
+	return blog.HookDropped()
+}
+
 // SetHookLevel set when hook will be called
 func SetHookLevel(level LevelType) {
 	blog.SetHookLevel(level)
@@ -443,6 +683,71 @@ func SetHookAsync(async bool) {
 	blog.SetHookAsync(async)
 }
 
+// SetErrorHandler set handler called with every error met by the writer
+func SetErrorHandler(handler ErrorHandler) {
+	blog.SetErrorHandler(handler)
+}
+
+// SetFallback set what to do with a message when it can not be written
+func SetFallback(policy FallbackPolicy) {
+	blog.SetFallback(policy)
+}
+
+// SetFlushLevel set level at or above which messages are flushed at once
+func SetFlushLevel(level LevelType) {
+	blog.SetFlushLevel(level)
+}
+
+// SetFlushInterval set interval of flushing in background
+func SetFlushInterval(interval time.Duration) {
+	blog.SetFlushInterval(interval)
+}
+
+// SetSyncPolicy set when files are synced to disk
+func SetSyncPolicy(policy SyncPolicy, interval time.Duration) {
+	blog.SetSyncPolicy(policy, interval)
+}
+
+// Errors return number of errors met by the writer
+func Errors() uint64 {
+	return blog.Errors()
+}
+
+// SetEncoder set encoder formatting log lines
+func SetEncoder(encoder Encoder) {
+	blog.SetEncoder(encoder)
+}
+
+// Name get name of the logger
+func Name() string {
+	return blog.Name()
+}
+
+// SetName set name of the logger, written by %c of PatternEncoder
+func SetName(name string) {
+	blog.SetName(name)
+}
+
+// ReportCaller get whether caller of logging actions is captured
+func ReportCaller() bool {
+	return blog.ReportCaller()
+}
+
+// SetReportCaller set whether caller of logging actions is captured
+func SetReportCaller(reportCaller bool) {
+	blog.SetReportCaller(reportCaller)
+}
+
+// CallerLevel get lowest level which caller is captured
+func CallerLevel() LevelType {
+	return blog.CallerLevel()
+}
+
+// SetCallerLevel set lowest level which caller is captured
+func SetCallerLevel(level LevelType) {
+	blog.SetCallerLevel(level)
+}
+
 // Colored get whether it is log with colored
 func Colored() bool {
 	return blog.Colored()
@@ -473,6 +778,36 @@ func SetRetentions(retentions int64) {
 	blog.SetRetentions(retentions)
 }
 
+// RotatePeriod get period of time base logrotate
+func RotatePeriod() time.Duration {
+	return blog.RotatePeriod()
+}
+
+// SetRotatePeriod set period of time base logrotate
+func SetRotatePeriod(period time.Duration) {
+	blog.SetRotatePeriod(period)
+}
+
+// MaxAge get max age of rotated files
+func MaxAge() time.Duration {
+	return blog.MaxAge()
+}
+
+// SetMaxAge set max age of rotated files
+func SetMaxAge(maxAge time.Duration) {
+	blog.SetMaxAge(maxAge)
+}
+
+// MaxBytes get max total size of logs
+func MaxBytes() int64 {
+	return blog.MaxBytes()
+}
+
+// SetMaxBytes set max total size of logs
+func SetMaxBytes(maxBytes int64) {
+	blog.SetMaxBytes(maxBytes)
+}
+
 // RotateSize get rotateSize
 func RotateSize() int64 {
 	return blog.RotateSize()
@@ -493,69 +828,118 @@ func SetRotateLines(rotateLines int) {
 	blog.SetRotateLines(rotateLines)
 }
 
+// WithFields derive a writer attaching fields to every message
+func WithFields(fields Fields) Writer {
+	return blog.WithFields(fields)
+}
+
+// With derive a writer attaching alternating keys and values as fields to
+// every message
+func With(pairs ...interface{}) Writer {
+	return blog.With(pairs...)
+}
+
 // Flush flush logs to disk
 func Flush() {
 	blog.flush()
 }
 
+// Reopen reopen files written by the default writer, such as after moved
+// by logrotate
+func Reopen() error {
+	return blog.Reopen()
+}
+
+// Rotate rotate files written by the default writer at once, such as at
+// the start of a deployment
+func Rotate() error {
+	return blog.Rotate()
+}
+
 // Trace static function for Trace
 func Trace(args ...interface{}) {
-	blog.Trace(args...)
+	blog.write(TRACE, nil, args...)
 }
 
 // Tracef static function for Tracef
 func Tracef(format string, args ...interface{}) {
-	blog.Tracef(format, args...)
+	blog.writef(TRACE, nil, format, args...)
 }
 
 // Debug static function for Debug
 func Debug(args ...interface{}) {
-	blog.Debug(args...)
+	blog.write(DEBUG, nil, args...)
 }
 
 // Debugf static function for Debugf
 func Debugf(format string, args ...interface{}) {
-	blog.Debugf(format, args...)
+	blog.writef(DEBUG, nil, format, args...)
 }
 
 // Info static function for Info
 func Info(args ...interface{}) {
-	blog.Info(args...)
+	blog.write(INFO, nil, args...)
 }
 
 // Infof static function for Infof
 func Infof(format string, args ...interface{}) {
-	blog.Infof(format, args...)
+	blog.writef(INFO, nil, format, args...)
 }
 
 // Warn static function for Warn
 func Warn(args ...interface{}) {
-	blog.Warn(args...)
+	blog.write(WARNING, nil, args...)
 }
 
 // Warnf static function for Warnf
 func Warnf(format string, args ...interface{}) {
-	blog.Warnf(format, args...)
+	blog.writef(WARNING, nil, format, args...)
 }
 
 // Error static function for Error
 func Error(args ...interface{}) {
-	blog.Error(args...)
+	blog.write(ERROR, nil, args...)
 }
 
 // Errorf static function for Errorf
 func Errorf(format string, args ...interface{}) {
-	blog.Errorf(format, args...)
+	blog.writef(ERROR, nil, format, args...)
 }
 
 // Critical static function for Critical
 func Critical(args ...interface{}) {
-	blog.Critical(args...)
+	blog.write(CRITICAL, nil, args...)
 }
 
 // Criticalf static function for Criticalf
 func Criticalf(format string, args ...interface{}) {
-	blog.Criticalf(format, args...)
+	blog.writef(CRITICAL, nil, format, args...)
+}
+
+// Fatal static function for Fatal, the process exits with code 1 after
+// logs are flushed
+func Fatal(args ...interface{}) {
+	blog.write(FATAL, nil, args...)
+	fatal()
+}
+
+// Fatalf static function for Fatalf
+func Fatalf(format string, args ...interface{}) {
+	blog.writef(FATAL, nil, format, args...)
+	fatal()
+}
+
+// Panic static function for Panic, it panics with message after logs are
+// flushed
+func Panic(args ...interface{}) {
+	blog.write(PANIC, nil, args...)
+	panicking(fmt.Sprint(args...))
+}
+
+// Panicf static function for Panicf
+func Panicf(format string, args ...interface{}) {
+	blog.writef(PANIC, nil, format, args...)
+	panicking(fmt.Sprintf(format, args...))
 }
 
 // Close close the logger
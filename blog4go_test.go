@@ -3,7 +3,11 @@
 package blog4go
 
 import (
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path"
+	"strings"
 	"testing"
 	"time"
 )
@@ -108,3 +112,76 @@ func TestGlobalOperation(t *testing.T) {
 
 	SetBufferSize(0)
 }
+
+func TestIndependentLoggers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	first, err := NewBaseFileLogger(path.Join(dir, "first.log"), false)
+	if nil != err {
+		t.Fatalf("create first logger failed. err: %s", err.Error())
+	}
+	second, err := NewBaseFileLogger(path.Join(dir, "second.log"), false)
+	if nil != err {
+		t.Fatalf("create second logger failed. err: %s", err.Error())
+	}
+
+	if nil != Default() {
+		t.Fatal("independent loggers should not set the default writer")
+	}
+
+	// colors of one logger never affect the other
+	second.SetColored(true)
+	first.Info("first")
+	second.SetLevel(WARNING)
+	second.Info("dropped")
+	second.Warn("second")
+	first.Close()
+	second.Close()
+
+	content := readFile(t, path.Join(dir, "first.log"))
+	if !strings.HasSuffix(content, "[INFO] first\n") {
+		t.Errorf("first logger output wrong: %q", content)
+	}
+	content = readFile(t, path.Join(dir, "second.log"))
+	if !strings.HasSuffix(content, "[\x1b[33mWARN\x1b[0m] second\n") || strings.Contains(content, "dropped") {
+		t.Errorf("second logger output wrong: %q", content)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewBaseFileLogger(path.Join(dir, "default.log"), false)
+	if nil != err {
+		t.Fatalf("create logger failed. err: %s", err.Error())
+	}
+
+	if replaced := SetDefault(writer); nil != replaced {
+		t.Error("no default writer should be replaced")
+	}
+	if writer != Default() {
+		t.Error("default writer not replaced")
+	}
+	if err = NewConsoleWriter(false); ErrAlreadyInit != err {
+		t.Errorf("singleton constructor should fail once default is set. err: %v", err)
+	}
+
+	Info("default")
+	if replaced := SetDefault(nil); writer != replaced {
+		t.Error("replaced writer not returned")
+	}
+	writer.Close()
+
+	content := readFile(t, path.Join(dir, "default.log"))
+	if !strings.HasSuffix(content, "[INFO] default\n") {
+		t.Errorf("default logger output wrong: %q", content)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// callerSkip is the number of frames between captureCaller and the user
+	// code calling a logging function. Every writer captures caller in its
+	// write/writef, which is called directly by logging methods of writers,
+	// fields writers and package level functions. Frames of fields writers
+	// derived from the default writer are skipped as well.
+	callerSkip = 2
+
+	// CallerKey is the field key of caller passed to FieldsHook
+	CallerKey = "caller"
+)
+
+var (
+	// deriveFuncs are names of functions passing logs of package level
+	// functions to the writer derived from
+	deriveFuncs = make(map[string]bool)
+
+	// recoverFuncs are names of functions logging panics recovered, callers
+	// captured in them are replaced by the functions panicked
+	recoverFuncs = make(map[string]bool)
+)
+
+func init() {
+	for _, fn := range []interface{}{(*fieldsWriter).write, (*fieldsWriter).writef} {
+		deriveFuncs[funcName(fn)] = true
+	}
+	for _, fn := range []interface{}{Recover, RecoverAndLog, recovered} {
+		recoverFuncs[funcName(fn)] = true
+	}
+}
+
+// funcName return name of function fn
+func funcName(fn interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
+
+// captureCaller return the caller skip frames above the function calling
+// captureCaller, nil if unknown. When recovering a panic, it is the
+// function panicked, frames of recover functions and the runtime are
+// skipped.
+func captureCaller(skip int) *Caller {
+	var pcs [16]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(skip+1, pcs[:])])
+
+	// the logging function, after fields writers it called
+	frame, more := frames.Next()
+	for more && deriveFuncs[frame.Function] {
+		frame, more = frames.Next()
+	}
+	if !more {
+		return nil
+	}
+
+	frame, more = frames.Next()
+	if recoverFuncs[frame.Function] {
+		for more && (recoverFuncs[frame.Function] || strings.HasPrefix(frame.Function, "runtime.")) {
+			frame, more = frames.Next()
+		}
+	}
+	if "" == frame.File {
+		return nil
+	}
+	return &Caller{File: frame.File, Line: frame.Line, Function: frame.Function}
+}
+
+// String return caller in "dir/file.go:line" form, only the last directory
+// of the file path is kept
+func (caller *Caller) String() string {
+	file := caller.File
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	return file + ":" + strconv.Itoa(caller.Line)
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// nextLine return the line number next to the caller
+func nextLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line + 1
+}
+
+func TestCaptureCaller(t *testing.T) {
+	caller := captureCaller(0)
+	if nil == caller || !strings.HasSuffix(caller.File, "caller_test.go") || !strings.HasSuffix(caller.Function, "TestCaptureCaller") {
+		t.Fatalf("capture caller wrong. caller: %+v", caller)
+	}
+
+	if !strings.HasSuffix(caller.String(), fmt.Sprintf("/caller_test.go:%d", caller.Line)) || 1 != strings.Count(caller.String(), "/") {
+		t.Errorf("caller string wrong. caller: %s", caller.String())
+	}
+}
+
+func TestReportCaller(t *testing.T) {
+	err := NewFileWriter("/tmp", false)
+	defer func() {
+		Close()
+
+		// clean logs
+		_, err = exec.Command("/bin/sh", "-c", "/bin/rm /tmp/*.log*").Output()
+		if nil != err {
+			t.Errorf("clean files failed. err: %s", err.Error())
+		}
+	}()
+	if nil != err {
+		t.Fatal(err.Error())
+	}
+
+	encoder, _ := NewPatternEncoder("%F:%L %M - %m")
+	SetEncoder(encoder)
+	SetReportCaller(true)
+
+	hook := &MyFieldsHook{l: new(sync.RWMutex)}
+	SetHook(hook)
+	SetHookAsync(false)
+
+	var lines []int
+	lines = append(lines, nextLine())
+	Info("package")
+	lines = append(lines, nextLine())
+	Infof("package %s", "formatted")
+	lines = append(lines, nextLine())
+	blog.Info("method")
+	lines = append(lines, nextLine())
+	blog.Infof("method %s", "formatted")
+	lines = append(lines, nextLine())
+	With("id", 1).Info("fields")
+	lines = append(lines, nextLine())
+	With("id", 1).Infof("fields %s", "formatted")
+
+	if caller, ok := hook.Fields()[CallerKey].(*Caller); !ok || lines[len(lines)-1] != caller.Line {
+		t.Errorf("hook should receive caller. fields: %+v", hook.Fields())
+	}
+
+	// only capture caller of warn and above
+	SetCallerLevel(WARNING)
+	Info("no caller")
+	lines = append(lines, nextLine())
+	Warn("warn")
+	Flush()
+
+	content := strings.Split(strings.TrimSpace(readFile(t, "/tmp/info.log")), "\n")
+	if 7 != len(content) {
+		t.Fatalf("lines written wrong. content: %s", content)
+	}
+
+	for i, line := range lines[:6] {
+		location := strings.SplitN(content[i], " ", 2)[0]
+		if fmt.Sprintf("caller_test.go:%d", line) != location || !strings.Contains(content[i], ".TestReportCaller - ") {
+			t.Errorf("caller of line %d wrong. line: %s, expected line number: %d", i, content[i], line)
+		}
+	}
+
+	if "?:? ? - no caller" != content[6] {
+		t.Errorf("caller should not be captured below caller level. line: %s", content[6])
+	}
+
+	warn := strings.TrimSpace(readFile(t, "/tmp/warn.log"))
+	if !strings.Contains(warn, fmt.Sprintf("caller_test.go:%d ", lines[6])) {
+		t.Errorf("caller of warn wrong. line: %s", warn)
+	}
+}
+
+func TestReportCallerDerivedDefault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "derived.log")
+	writer, err := NewBaseFileLogger(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	encoder, _ := NewPatternEncoder("%F:%L %m")
+	writer.SetEncoder(encoder)
+	writer.SetReportCaller(true)
+	replaced := SetDefault(writer.With("id", 1))
+	defer SetDefault(replaced)
+
+	var lines []int
+	lines = append(lines, nextLine())
+	Info("package")
+	lines = append(lines, nextLine())
+	Infof("package %s", "formatted")
+	writer.flush()
+
+	content := strings.Split(strings.TrimSpace(readFile(t, name)), "\n")
+	if 2 != len(content) {
+		t.Fatalf("lines written wrong. content: %s", content)
+	}
+	for i, line := range lines {
+		if location := strings.SplitN(content[i], " ", 2)[0]; fmt.Sprintf("caller_test.go:%d", line) != location {
+			t.Errorf("caller of line %d wrong. line: %s, expected line number: %d", i, content[i], line)
+		}
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -31,6 +34,8 @@ var (
 	ErrConfigSocketAddressNotFound = errors.New("Please define a socket address")
 	// ErrConfigSocketNetworkNotFound not found socket port
 	ErrConfigSocketNetworkNotFound = errors.New("Please define a socket network type")
+	// ErrConfigSinkTypeNotFound not found sink type
+	ErrConfigSinkTypeNotFound = errors.New("Please define the sink type")
 )
 
 // Config struct define the config struct used for file wirter
@@ -41,16 +46,30 @@ type Config struct {
 
 // log filter
 type filter struct {
-	Levels     string     `xml:"levels,attr"`
-	Colored    bool       `xml:"colored,attr"`
-	File       file       `xml:"file"`
-	RotateFile rotateFile `xml:"rotatefile"`
-	Console    console    `xml:"console"`
-	Socket     socket     `xml:"socket"`
+	Levels      string     `xml:"levels,attr"`
+	Colored     bool       `xml:"colored,attr"`
+	Format      string     `xml:"format,attr"`
+	Pattern     string     `xml:"pattern,attr"`
+	Caller      bool       `xml:"caller,attr"`
+	CallerLevel string     `xml:"callerLevel,attr"`
+	Fallback    string     `xml:"fallback,attr"`
+	File        file       `xml:"file"`
+	RotateFile  rotateFile `xml:"rotatefile"`
+	Console     console    `xml:"console"`
+	Socket      socket     `xml:"socket"`
+	Sink        *sink      `xml:"sink"`
+
+	// interval of flushing in background, such as "100ms"
+	FlushInterval string `xml:"flushInterval,attr"`
+	// messages at or above the level are flushed at once
+	FlushLevel string `xml:"flushLevel,attr"`
+	// when files are synced to disk, see SyncFromString
+	Sync string `xml:"sync,attr"`
 }
 
 type file struct {
 	Path string `xml:"path,attr"`
+	fileAttrs
 }
 
 type rotateFile struct {
@@ -59,6 +78,27 @@ type rotateFile struct {
 	RotateLines int    `xml:"rotateLines,attr"`
 	RotateSize  int64  `xml:"rotateSize,attr"`
 	Retentions  int64  `xml:"retentions,attr"`
+	// period of time base logrotate, see ParsePeriod
+	Period string `xml:"period,attr"`
+	// max age of rotated files, such as "72h" or "7d"
+	MaxAge string `xml:"maxAge,attr"`
+	// max total size of logs
+	MaxBytes int64 `xml:"maxBytes,attr"`
+	// name template of files rotated by size or lines, see DefaultNameTemplate
+	NameTemplate string `xml:"nameTemplate,attr"`
+	// path template of files rotated by time with date directories, such
+	// as "logs/%d{2006/01/02}/%f"
+	PathTemplate string `xml:"pathTemplate,attr"`
+	// keep a symlink at path pointing to the current file
+	Symlink bool `xml:"symlink,attr"`
+	// command run with path of every file archived, see CommandRotateHandler
+	OnRotate string `xml:"onRotate,attr"`
+	// compress rotated files with gzip
+	Compress bool `xml:"compress,attr"`
+	// rotate the file not empty at once when created, see FileSink.Rotate
+	RotateOnStartup bool `xml:"rotateOnStartup,attr"`
+	// about files opened
+	fileAttrs
 }
 
 type console struct {
@@ -71,6 +111,22 @@ type socket struct {
 	Address string `xml:"address,attr"`
 }
 
+// sink element of a type registered by RegisterSink, every other attribute
+// is passed to the SinkFactory
+type sink struct {
+	Type  string     `xml:"type,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// attrs return attributes except type by name
+func (sink *sink) attrs() map[string]string {
+	attrs := make(map[string]string, len(sink.Attrs))
+	for _, attr := range sink.Attrs {
+		attrs[attr.Name.Local] = attr.Value
+	}
+	return attrs
+}
+
 // check if config is valid
 func (config *Config) valid() error {
 	// check minlevel validation
@@ -89,11 +145,41 @@ func (config *Config) valid() error {
 			return ErrConfigLevelsNotFound
 		}
 
+		if _, err := filter.encoder(); nil != err {
+			return err
+		}
+
+		if "" != filter.CallerLevel && !LevelFromString(filter.CallerLevel).valid() {
+			return ErrConfigBadAttributes
+		}
+
+		if _, ok := FallbackFromString(filter.Fallback); "" != filter.Fallback && !ok {
+			return ErrConfigBadAttributes
+		}
+
+		if "" != filter.FlushInterval {
+			if interval, err := time.ParseDuration(filter.FlushInterval); nil != err || interval <= 0 {
+				return ErrConfigBadAttributes
+			}
+		}
+
+		if "" != filter.FlushLevel && !LevelFromString(filter.FlushLevel).valid() {
+			return ErrConfigBadAttributes
+		}
+
+		if _, _, ok := SyncFromString(filter.Sync); "" != filter.Sync && !ok {
+			return ErrConfigBadAttributes
+		}
+
 		if (file{}) != filter.File {
 			// seem not needed now
 			//if "" == filter.File.Path {
 			//return ErrConfigFilePathNotFound
 			//}
+
+			if _, err := filter.File.options(); nil != err {
+				return err
+			}
 		} else if (rotateFile{}) != filter.RotateFile {
 			if "" == filter.RotateFile.Path {
 				return ErrConfigFilePathNotFound
@@ -102,6 +188,32 @@ func (config *Config) valid() error {
 			if "" == filter.RotateFile.Type {
 				return ErrConfigFileRotateTypeNotFound
 			}
+
+			if "" != filter.RotateFile.Period {
+				if _, err := ParsePeriod(filter.RotateFile.Period); nil != err {
+					return err
+				}
+			}
+
+			if _, err := parseAge(filter.RotateFile.MaxAge); nil != err || filter.RotateFile.MaxBytes < 0 {
+				return ErrConfigBadAttributes
+			}
+
+			if _, err := filter.RotateFile.options(); nil != err {
+				return err
+			}
+
+			if "" != filter.RotateFile.NameTemplate {
+				if _, err := newNameTemplate(filter.RotateFile.NameTemplate); nil != err {
+					return err
+				}
+			}
+
+			if "" != filter.RotateFile.PathTemplate {
+				if _, err := newPathTemplate(filter.RotateFile.PathTemplate); nil != err {
+					return err
+				}
+			}
 		} else if (socket{}) != filter.Socket {
 			if "" == filter.Socket.Address {
 				return ErrConfigSocketAddressNotFound
@@ -110,12 +222,51 @@ func (config *Config) valid() error {
 			if "" == filter.Socket.Network {
 				return ErrConfigSocketNetworkNotFound
 			}
+		} else if nil != filter.Sink {
+			if "" == filter.Sink.Type {
+				return ErrConfigSinkTypeNotFound
+			}
 		}
 	}
 
 	return nil
 }
 
+// encoder return the encoder of a filter, a pattern layout can only be
+// used with the text format
+func (filter *filter) encoder() (Encoder, error) {
+	if "" == filter.Pattern {
+		return encoderFromFormat(filter.Format)
+	}
+
+	if "" != filter.Format && FormatText != filter.Format {
+		return nil, ErrConfigBadAttributes
+	}
+	return NewPatternEncoder(filter.Pattern)
+}
+
+// parseAge parse max age of rotated files, it accepts durations of
+// time.ParseDuration and whole days such as "7d", empty means no limit
+func parseAge(age string) (time.Duration, error) {
+	if "" == age {
+		return 0, nil
+	}
+
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if nil != err || days < 0 {
+			return 0, ErrConfigBadAttributes
+		}
+		return time.Duration(days) * PeriodDay, nil
+	}
+
+	duration, err := time.ParseDuration(age)
+	if nil != err || duration < 0 {
+		return 0, ErrConfigBadAttributes
+	}
+	return duration, nil
+}
+
 // read config from a xml file
 func readConfig(fileName string) (*Config, error) {
 	file, err := os.Open(fileName)
@@ -3,6 +3,12 @@
 package blog4go
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
 	"testing"
 )
 
@@ -136,4 +142,255 @@ func TestConfigValidation(t *testing.T) {
 	if err := config.valid(); ErrConfigLevelsNotFound == err || ErrConfigSocketAddressNotFound == err || ErrConfigSocketNetworkNotFound == err {
 		t.Error("config socket filter check failed.")
 	}
+
+	// format check
+	f = filter{
+		Levels: "debug",
+		Format: "xml",
+	}
+	config.Filters = make([]filter, 0)
+	config.Filters = append(config.Filters, f)
+
+	if err := config.valid(); ErrInvalidFormat != err {
+		t.Error("config filter format check failed.")
+	}
+
+	// pattern check
+	f = filter{
+		Levels:  "debug",
+		Pattern: "%p %Q",
+	}
+	config.Filters = make([]filter, 0)
+	config.Filters = append(config.Filters, f)
+
+	if err := config.valid(); ErrInvalidPattern != err {
+		t.Error("config filter pattern check failed.")
+	}
+
+	f = filter{
+		Levels:  "debug",
+		Format:  "json",
+		Pattern: "%p %m",
+	}
+	config.Filters = make([]filter, 0)
+	config.Filters = append(config.Filters, f)
+
+	if err := config.valid(); ErrConfigBadAttributes != err {
+		t.Error("config filter pattern with json format check failed.")
+	}
+
+	// caller level check
+	f = filter{
+		Levels:      "debug",
+		Caller:      true,
+		CallerLevel: "something",
+	}
+	config.Filters = make([]filter, 0)
+	config.Filters = append(config.Filters, f)
+
+	if err := config.valid(); ErrConfigBadAttributes != err {
+		t.Error("config filter caller level check failed.")
+	}
+}
+
+func TestConfigFiltersFanOut(t *testing.T) {
+	configFile := "/tmp/blog4go_fanout.xml"
+	config := `<blog4go minlevel="debug">
+	<filter levels="debug,info">
+		<file path="/tmp/fanout.log"></file>
+	</filter>
+	<filter levels="debug">
+		<rotatefile path="/tmp/fanout_rotate.log" type="size" rotateSize="50000000" retentions="10"></rotatefile>
+	</filter>
+	<filter levels="debug">
+		<console></console>
+	</filter>
+</blog4go>`
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); nil != err {
+		t.Fatal(err.Error())
+	}
+
+	// capture console output
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if nil != err {
+		t.Fatal(err.Error())
+	}
+	os.Stdout = w
+
+	err = NewWriterFromConfigAsFile(configFile)
+	os.Stdout = stdout
+	defer func() {
+		Close()
+		os.Remove(configFile)
+
+		// clean logs
+		_, err = exec.Command("/bin/sh", "-c", "/bin/rm /tmp/*.log*").Output()
+		if nil != err {
+			t.Errorf("clean files failed. err: %s", err.Error())
+		}
+	}()
+	if nil != err {
+		t.Fatal(err.Error())
+	}
+
+	Debug("fan out")
+	Infof("only %s", "file")
+	Close()
+
+	w.Close()
+	consoleOut, _ := ioutil.ReadAll(r)
+
+	for _, out := range []string{readFile(t, "/tmp/fanout.log"), readFile(t, "/tmp/fanout_rotate.log"), string(consoleOut)} {
+		if !strings.Contains(out, "[DEBUG] fan out") {
+			t.Errorf("debug message not written to every filter. out: %s", out)
+		}
+	}
+
+	if !strings.Contains(readFile(t, "/tmp/fanout.log"), "[INFO] only file") {
+		t.Error("info message not written to file filter.")
+	}
+
+	if strings.Contains(string(consoleOut), "only file") || strings.Contains(readFile(t, "/tmp/fanout_rotate.log"), "only file") {
+		t.Error("info message written to filters without info level.")
+	}
+}
+
+func TestConfigFilterEncoders(t *testing.T) {
+	configFile := "/tmp/blog4go_json.xml"
+	config := `<blog4go>
+	<filter levels="info" format="json">
+		<file path="/tmp/json.log"></file>
+	</filter>
+	<filter levels="info">
+		<file path="/tmp/text.log"></file>
+	</filter>
+	<filter levels="info" pattern="%p %c - %m%X">
+		<file path="/tmp/pattern.log"></file>
+	</filter>
+</blog4go>`
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); nil != err {
+		t.Fatal(err.Error())
+	}
+
+	err := NewWriterFromConfigAsFile(configFile)
+	defer func() {
+		Close()
+		os.Remove(configFile)
+
+		// clean logs
+		_, err = exec.Command("/bin/sh", "-c", "/bin/rm /tmp/*.log*").Output()
+		if nil != err {
+			t.Errorf("clean files failed. err: %s", err.Error())
+		}
+	}()
+	if nil != err {
+		t.Fatal(err.Error())
+	}
+
+	SetName("app")
+	With("request_id", 12).Infof("hello %s", "json")
+	Close()
+
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(readFile(t, "/tmp/json.log")), &line); nil != err {
+		t.Fatalf("json filter output invalid. err: %s", err.Error())
+	}
+	if "INFO" != line["level"] || "hello json" != line["msg"] || float64(12) != line["request_id"] {
+		t.Errorf("json filter output wrong. line: %+v", line)
+	}
+
+	if !strings.HasSuffix(readFile(t, "/tmp/text.log"), "[INFO] hello json request_id=12\n") {
+		t.Errorf("text filter output wrong. out: %s", readFile(t, "/tmp/text.log"))
+	}
+
+	if "INFO app - hello json request_id=12\n" != readFile(t, "/tmp/pattern.log") {
+		t.Errorf("pattern filter output wrong. out: %s", readFile(t, "/tmp/pattern.log"))
+	}
+}
+
+func TestConfigPatternCaller(t *testing.T) {
+	configFile := "/tmp/blog4go_caller.xml"
+	config := `<blog4go>
+	<filter levels="info" pattern="%F:%L %m">
+		<file path="/tmp/caller.log"></file>
+	</filter>
+</blog4go>`
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); nil != err {
+		t.Fatal(err.Error())
+	}
+
+	err := NewWriterFromConfigAsFile(configFile)
+	defer func() {
+		Close()
+		os.Remove(configFile)
+
+		// clean logs
+		_, err = exec.Command("/bin/sh", "-c", "/bin/rm /tmp/*.log*").Output()
+		if nil != err {
+			t.Errorf("clean files failed. err: %s", err.Error())
+		}
+	}()
+	if nil != err {
+		t.Fatal(err.Error())
+	}
+
+	// caller conversions report caller without caller="true"
+	line := nextLine()
+	Info("x")
+	Close()
+
+	if expected := fmt.Sprintf("config_test.go:%d x\n", line); expected != readFile(t, "/tmp/caller.log") {
+		t.Errorf("caller of pattern not reported. expected: %q, out: %q", expected, readFile(t, "/tmp/caller.log"))
+	}
+}
+
+func TestConfigFilterColored(t *testing.T) {
+	configFile := "/tmp/blog4go_colored.xml"
+	config := `<blog4go>
+	<filter levels="debug" colored="true">
+		<file path="/tmp/colored.log"></file>
+	</filter>
+	<filter levels="info">
+		<file path="/tmp/plain.log"></file>
+	</filter>
+</blog4go>`
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); nil != err {
+		t.Fatal(err.Error())
+	}
+
+	err := NewWriterFromConfigAsFile(configFile)
+	defer func() {
+		Close()
+		os.Remove(configFile)
+
+		// clean logs
+		_, err = exec.Command("/bin/sh", "-c", "/bin/rm /tmp/*.log*").Output()
+		if nil != err {
+			t.Errorf("clean files failed. err: %s", err.Error())
+		}
+	}()
+	if nil != err {
+		t.Fatal(err.Error())
+	}
+
+	// every filter keeps its own color
+	Debug("colored")
+	Info("plain")
+	Close()
+
+	if !strings.HasSuffix(readFile(t, "/tmp/colored.log"), "[\x1b[32mDEBUG\x1b[0m] colored\n") {
+		t.Errorf("colored filter output wrong. out: %q", readFile(t, "/tmp/colored.log"))
+	}
+	if !strings.HasSuffix(readFile(t, "/tmp/plain.log"), "[INFO] plain\n") {
+		t.Errorf("plain filter output wrong. out: %q", readFile(t, "/tmp/plain.log"))
+	}
+}
+
+func readFile(t *testing.T, fileName string) string {
+	content, err := ioutil.ReadFile(fileName)
+	if nil != err {
+		t.Errorf("read file failed. err: %s", err.Error())
+	}
+	return string(content)
 }
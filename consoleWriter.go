@@ -3,13 +3,14 @@
 package blog4go
 
 import (
-	"fmt"
 	"os"
+	"sync"
 	"time"
 )
 
-// ConsoleWriter is a console logger
-type ConsoleWriter struct {
+// ConsoleSink is a console sink, messages at or above WARNING are written
+// to stderr unless redirected
+type ConsoleSink struct {
 	blog *BLog
 	// for stderr
 	errblog *BLog
@@ -20,13 +21,26 @@ type ConsoleWriter struct {
 
 	colored bool
 
-	// log hook
-	hook      Hook
-	hookLevel LevelType
-	hookAsync bool
+	// interval of flushing in background, default DefaultFlushInterval
+	flushInterval time.Duration
+	flushTicker   *time.Ticker
+
+	// handler of errors met in background
+	errorHandler ErrorHandler
+
+	// lock of closed and settings, held shared while writing
+	lock *sync.RWMutex
 }
 
-// NewConsoleWriter initialize a console writer, singlton
+// ConsoleWriter is the writer created by NewConsoleWriter and
+// NewConsoleLogger.
+//
+// Deprecated: use Writer, console writers are a Writer with a ConsoleSink.
+type ConsoleWriter struct {
+	*sinkWriter
+}
+
+// NewConsoleWriter initialize the default writer as a console writer, singlton
 func NewConsoleWriter(redirected bool) (err error) {
 	singltonLock.Lock()
 	defer singltonLock.Unlock()
@@ -40,305 +54,181 @@ func NewConsoleWriter(redirected bool) (err error) {
 	}
 
 	blog = consoleWriter
-	go consoleWriter.daemon()
 	return nil
 }
 
-// newConsoleWriter initialize a console writer, not singlton
+// NewConsoleLogger create an independent console writer, not singlton
 // if redirected, stderr will be redirected to stdout
-func newConsoleWriter(redirected bool) (consoleWriter *ConsoleWriter, err error) {
-	consoleWriter = new(ConsoleWriter)
-	consoleWriter.blog = NewBLog(os.Stdout)
-	consoleWriter.redirected = redirected
-	if !redirected {
-		consoleWriter.errblog = NewBLog(os.Stderr)
+func NewConsoleLogger(redirected bool) (Writer, error) {
+	writer, err := newConsoleWriter(redirected)
+	if nil != err {
+		return nil, err
 	}
+	return writer, nil
+}
 
-	consoleWriter.closed = false
+// newConsoleWriter create a console writer, not singlton
+func newConsoleWriter(redirected bool) (*ConsoleWriter, error) {
+	sink, err := NewConsoleSink(redirected)
+	if nil != err {
+		return nil, err
+	}
+	return &ConsoleWriter{sinkWriter: newSinkWriter(sink)}, nil
+}
 
-	consoleWriter.colored = false
+// NewConsoleSink initialize a console sink
+// if redirected, stderr will be redirected to stdout
+func NewConsoleSink(redirected bool) (sink *ConsoleSink, err error) {
+	sink = new(ConsoleSink)
+	sink.blog = NewBLog(os.Stdout)
+	sink.redirected = redirected
+	if !redirected {
+		sink.errblog = NewBLog(os.Stderr)
+	}
 
-	// log hook
-	consoleWriter.hook = nil
-	consoleWriter.hookLevel = DEBUG
-	consoleWriter.hookAsync = true
+	sink.closed = false
+	sink.lock = new(sync.RWMutex)
 
-	go consoleWriter.daemon()
+	sink.colored = false
 
-	blog = consoleWriter
-	return consoleWriter, nil
+	sink.flushInterval = DefaultFlushInterval
+	sink.flushTicker = time.NewTicker(sink.flushInterval)
+	go sink.daemon()
+
+	return sink, nil
 }
 
-func (writer *ConsoleWriter) daemon() {
-	f := time.Tick(1 * time.Second)
+func (sink *ConsoleSink) daemon() {
+	defer sink.flushTicker.Stop()
 
 DaemonLoop:
 	for {
 		select {
-		case <-f:
-			if writer.closed {
+		case <-sink.flushTicker.C:
+			if sink.Closed() {
 				break DaemonLoop
 			}
 
-			writer.flush()
-		}
-	}
-}
-
-func (writer *ConsoleWriter) write(level LevelType, args ...interface{}) {
-	if writer.closed {
-		return
-	}
-
-	defer func() {
-		if nil != writer.hook && !(level < writer.hookLevel) {
-			if writer.hookAsync {
-				go func(level LevelType, args ...interface{}) {
-					writer.hook.Fire(level, args...)
-				}(level, args...)
-
-			} else {
-				writer.hook.Fire(level, args...)
+			if err := sink.Flush(); nil != err {
+				sink.handleError(err)
 			}
 		}
-	}()
-
-	if !writer.redirected && level >= WARNING {
-		writer.errblog.write(level, args...)
-		return
 	}
-
-	writer.blog.write(level, args...)
 }
 
-func (writer *ConsoleWriter) writef(level LevelType, format string, args ...interface{}) {
-	if writer.closed {
-		return
-	}
-
-	defer func() {
+// WriteEntry writes an entry into stdout or stderr
+func (sink *ConsoleSink) WriteEntry(entry *Entry) error {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
 
-		if nil != writer.hook && !(level < writer.hookLevel) {
-			if writer.hookAsync {
-				go func(level LevelType, format string, args ...interface{}) {
-					writer.hook.Fire(level, fmt.Sprintf(format, args...))
-				}(level, format, args...)
-
-			} else {
-				writer.hook.Fire(level, fmt.Sprintf(format, args...))
-			}
-		}
-	}()
-
-	if !writer.redirected && level >= WARNING {
-		writer.errblog.writef(level, format, args...)
-		return
+	if sink.closed {
+		return ErrSinkClosed
 	}
 
-	writer.blog.writef(level, format, args...)
-}
-
-// Level get level
-func (writer *ConsoleWriter) Level() LevelType {
-	return writer.blog.Level()
-}
+	if !sink.redirected && entry.Level >= WARNING {
+		_, err := sink.errblog.write(entry)
+		return err
+	}
 
-// SetLevel set logger level
-func (writer *ConsoleWriter) SetLevel(level LevelType) {
-	writer.blog.SetLevel(level)
+	_, err := sink.blog.write(entry)
+	return err
 }
 
 // Colored get Colored
-func (writer *ConsoleWriter) Colored() bool {
-	return writer.colored
+func (sink *ConsoleSink) Colored() bool {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.colored
 }
 
 // SetColored set logging color
-func (writer *ConsoleWriter) SetColored(colored bool) {
-	if colored == writer.colored {
-		return
-	}
-
-	writer.colored = colored
-
-	initPrefix(colored)
-}
-
-// SetHook set hook for logging action
-func (writer *ConsoleWriter) SetHook(hook Hook) {
-	writer.hook = hook
-}
-
-// SetHookAsync set hook async for base file writer
-func (writer *ConsoleWriter) SetHookAsync(async bool) {
-	writer.hookAsync = async
-}
-
-// SetHookLevel set when hook will be called
-func (writer *ConsoleWriter) SetHookLevel(level LevelType) {
-	writer.hookLevel = level
-}
-
-// Close close console writer
-func (writer *ConsoleWriter) Close() {
-	if writer.closed {
+func (sink *ConsoleSink) SetColored(colored bool) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if colored == sink.colored {
 		return
 	}
 
-	writer.blog.flush()
-	writer.blog = nil
-	writer.closed = true
-}
-
-// TimeRotated do nothing
-func (writer *ConsoleWriter) TimeRotated() bool {
-	return false
-}
-
-// SetTimeRotated do nothing
-func (writer *ConsoleWriter) SetTimeRotated(timeRotated bool) {
-	return
-}
-
-// Retentions do nothing
-func (writer *ConsoleWriter) Retentions() int64 {
-	return 0
-}
-
-// SetRetentions do nothing
-func (writer *ConsoleWriter) SetRetentions(retentions int64) {
-	return
-}
-
-// RotateSize do nothing
-func (writer *ConsoleWriter) RotateSize() int64 {
-	return 0
-}
-
-// SetRotateSize do nothing
-func (writer *ConsoleWriter) SetRotateSize(rotateSize int64) {
-	return
-}
-
-// RotateLines do nothing
-func (writer *ConsoleWriter) RotateLines() int {
-	return 0
-}
-
-// SetRotateLines do nothing
-func (writer *ConsoleWriter) SetRotateLines(rotateLines int) {
-	return
-}
-
-// flush buffer to disk
-func (writer *ConsoleWriter) flush() {
-	writer.blog.flush()
-}
-
-// Trace trace
-func (writer *ConsoleWriter) Trace(args ...interface{}) {
-	if nil == writer.blog || TRACE < writer.blog.Level() {
-		return
+	sink.colored = colored
+	sink.blog.SetColored(colored)
+	if nil != sink.errblog {
+		sink.errblog.SetColored(colored)
 	}
-
-	writer.write(TRACE, args...)
 }
 
-// Tracef tracef
-func (writer *ConsoleWriter) Tracef(format string, args ...interface{}) {
-	if nil == writer.blog || TRACE < writer.blog.Level() {
-		return
+// SetEncoder set encoder formatting log lines
+func (sink *ConsoleSink) SetEncoder(encoder Encoder) {
+	sink.blog.SetEncoder(encoder)
+	if nil != sink.errblog {
+		sink.errblog.SetEncoder(encoder)
 	}
-
-	writer.writef(TRACE, format, args...)
 }
 
-// Debug debug
-func (writer *ConsoleWriter) Debug(args ...interface{}) {
-	if nil == writer.blog || DEBUG < writer.blog.Level() {
-		return
-	}
-
-	writer.write(DEBUG, args...)
+// FlushInterval get interval of flushing in background
+func (sink *ConsoleSink) FlushInterval() time.Duration {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.flushInterval
 }
 
-// Debugf debugf
-func (writer *ConsoleWriter) Debugf(format string, args ...interface{}) {
-	if nil == writer.blog || DEBUG < writer.blog.Level() {
+// SetFlushInterval set interval of flushing in background, it is ignored
+// if not positive
+func (sink *ConsoleSink) SetFlushInterval(interval time.Duration) {
+	if interval <= 0 {
 		return
 	}
 
-	writer.writef(DEBUG, format, args...)
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.flushInterval = interval
+	sink.flushTicker.Reset(interval)
 }
 
-// Info info
-func (writer *ConsoleWriter) Info(args ...interface{}) {
-	if nil == writer.blog || INFO < writer.blog.Level() {
-		return
-	}
-
-	writer.write(INFO, args...)
-}
-
-// Infof infof
-func (writer *ConsoleWriter) Infof(format string, args ...interface{}) {
-	if nil == writer.blog || INFO < writer.blog.Level() {
-		return
-	}
-
-	writer.writef(INFO, format, args...)
-}
-
-// Warn warn
-func (writer *ConsoleWriter) Warn(args ...interface{}) {
-	if nil == writer.blog || WARNING < writer.blog.Level() {
-		return
-	}
-
-	writer.write(WARNING, args...)
+// SetErrorHandler set handler of errors met while flushing in background
+func (sink *ConsoleSink) SetErrorHandler(handler ErrorHandler) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.errorHandler = handler
 }
 
-// Warnf warnf
-func (writer *ConsoleWriter) Warnf(format string, args ...interface{}) {
-	if nil == writer.blog || WARNING < writer.blog.Level() {
-		return
-	}
-
-	writer.writef(WARNING, format, args...)
-}
+// handleError pass err to the error handler if set
+func (sink *ConsoleSink) handleError(err error) {
+	sink.lock.RLock()
+	handler := sink.errorHandler
+	sink.lock.RUnlock()
 
-// Error error
-func (writer *ConsoleWriter) Error(args ...interface{}) {
-	if nil == writer.blog || ERROR < writer.blog.Level() {
-		return
+	if nil != handler {
+		handler(err)
 	}
-
-	writer.write(ERROR, args...)
 }
 
-// Errorf errorf
-func (writer *ConsoleWriter) Errorf(format string, args ...interface{}) {
-	if nil == writer.blog || ERROR < writer.blog.Level() {
-		return
-	}
-
-	writer.writef(ERROR, format, args...)
+// Closed get sink status
+func (sink *ConsoleSink) Closed() bool {
+	sink.lock.RLock()
+	defer sink.lock.RUnlock()
+	return sink.closed
 }
 
-// Critical critical
-func (writer *ConsoleWriter) Critical(args ...interface{}) {
-	if nil == writer.blog || CRITICAL < writer.blog.Level() {
-		return
+// Close close console sink
+func (sink *ConsoleSink) Close() error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if sink.closed {
+		return nil
 	}
 
-	writer.write(CRITICAL, args...)
+	err := sink.Flush()
+	sink.closed = true
+	return err
 }
 
-// Criticalf criticalf
-func (writer *ConsoleWriter) Criticalf(format string, args ...interface{}) {
-	if nil == writer.blog || CRITICAL < writer.blog.Level() {
-		return
+// Flush flush buffer to stdout and stderr
+func (sink *ConsoleSink) Flush() error {
+	err := sink.blog.flush()
+	if nil != sink.errblog {
+		if errblogErr := sink.errblog.flush(); nil == err {
+			err = errblogErr
+		}
 	}
-
-	writer.writef(CRITICAL, format, args...)
+	return err
 }
@@ -206,6 +206,9 @@ func TestSingleConsoleWriter(t *testing.T) {
 	if nil != err {
 		t.Error(err.Error())
 	}
+	if _, ok := Default().(*ConsoleWriter); !ok {
+		t.Error("default writer should be a ConsoleWriter")
+	}
 
 	// duplicate init check
 	err = NewConsoleWriter(true)
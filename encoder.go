@@ -0,0 +1,209 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// FormatText is the format name of TextEncoder
+	FormatText = "text"
+	// FormatJSON is the format name of JSONEncoder
+	FormatJSON = "json"
+
+	// JSONTimeFormat is the time format of "time" key in JSON log lines
+	JSONTimeFormat = time.RFC3339
+)
+
+var (
+	// DefaultEncoder is the encoder used by writers unless another one is set
+	DefaultEncoder Encoder = TextEncoder{}
+
+	// jsonReservedKeys are keys written by JSONEncoder itself, fields with
+	// these keys are written with a "fields." prefix instead
+	jsonReservedKeys = map[string]bool{"time": true, "level": true, "msg": true, "caller": true, "func": true}
+)
+
+// Buffer is the destination an Encoder writes a log line into.
+// Both *bufio.Writer and *bytes.Buffer satisfy it.
+type Buffer interface {
+	io.Writer
+	io.ByteWriter
+	io.StringWriter
+}
+
+// Encoder formats an Entry into a log line.
+// Encode writes the whole line including the trailing EOL to w and
+// returns number of bytes written.
+type Encoder interface {
+	Encode(w Buffer, entry *Entry) int
+}
+
+// encoderFromFormat return the encoder associated with a format name,
+// empty format name means the default text format
+func encoderFromFormat(format string) (Encoder, error) {
+	switch format {
+	case "", FormatText:
+		return TextEncoder{}, nil
+	case FormatJSON:
+		return JSONEncoder{}, nil
+	}
+	return nil, ErrInvalidFormat
+}
+
+// TextEncoder encodes entries in the text layout
+// "[2006/01/02:15:04:05] [LEVEL] message key=value", it is the default one.
+// Caller is written as "dir/file.go:line " ahead of message when known.
+// Formatted messages are written while parsing the format string.
+// Levels are written in colors if Colored.
+type TextEncoder struct {
+	Colored bool
+}
+
+// Encode writes entry in text layout
+func (encoder TextEncoder) Encode(w Buffer, entry *Entry) (size int) {
+	s, _ := w.Write(entry.timestamp)
+	size += s
+	s, _ = w.WriteString(entry.Level.prefix(encoder.Colored))
+	size += s
+	if nil != entry.Caller {
+		s, _ = w.WriteString(entry.Caller.String())
+		size += s
+		w.WriteByte(' ')
+		size++
+	}
+	size += entry.writeMessage(w)
+	size += entry.Fields.writeTo(w)
+	w.WriteByte(EOL)
+
+	return size + 1
+}
+
+// JSONEncoder encodes every entry as one JSON object per line with keys
+// "time", "level", "msg", "caller" and "func" when caller is known, and a
+// key for every field.
+// Fields named as these keys are written as "fields.time" and so on.
+type JSONEncoder struct{}
+
+// Encode writes entry as a JSON object
+func (encoder JSONEncoder) Encode(w Buffer, entry *Entry) (size int) {
+	s, _ := w.WriteString(`{"time":`)
+	size += s
+	size += writeJSONString(w, entry.Time.Format(JSONTimeFormat))
+	s, _ = w.WriteString(`,"level":`)
+	size += s
+	size += writeJSONString(w, entry.Level.String())
+	s, _ = w.WriteString(`,"msg":`)
+	size += s
+	size += writeJSONString(w, entry.Message())
+	if nil != entry.Caller {
+		s, _ = w.WriteString(`,"caller":`)
+		size += s
+		size += writeJSONString(w, entry.Caller.String())
+		s, _ = w.WriteString(`,"func":`)
+		size += s
+		size += writeJSONString(w, entry.Caller.Function)
+	}
+
+	for _, key := range entry.Fields.keys() {
+		w.WriteByte(',')
+		size++
+		if jsonReservedKeys[key] {
+			size += writeJSONString(w, "fields."+key)
+		} else {
+			size += writeJSONString(w, key)
+		}
+		w.WriteByte(':')
+		size++
+		size += writeJSONValue(w, entry.Fields[key])
+	}
+
+	s, _ = w.WriteString("}\n")
+	return size + s
+}
+
+// writeJSONValue writes value in JSON, errors are written as their message
+// and values can not be marshaled are written as fmt.Sprint does
+func writeJSONValue(w Buffer, value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return writeJSONString(w, v)
+	case error:
+		return writeJSONString(w, v.Error())
+	}
+
+	out, err := json.Marshal(value)
+	if nil != err {
+		return writeJSONString(w, fmt.Sprint(value))
+	}
+	s, _ := w.Write(out)
+	return s
+}
+
+// writeJSONString writes str as a quoted JSON string.
+// Unlike json.Marshal it does not escape HTML characters.
+func writeJSONString(w Buffer, str string) (size int) {
+	const hex = "0123456789abcdef"
+
+	w.WriteByte('"')
+	size++
+
+	// 未输出的，第一个普通字符位置
+	last := 0
+	for i := 0; i < len(str); {
+		c := str[i]
+		if c >= utf8.RuneSelf {
+			r, n := utf8.DecodeRuneInString(str[i:])
+			if utf8.RuneError == r && 1 == n {
+				s, _ := w.WriteString(str[last:i])
+				size += s
+				s, _ = w.WriteString("\ufffd")
+				size += s
+				last = i + n
+			}
+			i += n
+			continue
+		}
+
+		if c >= 0x20 && '"' != c && '\\' != c {
+			i++
+			continue
+		}
+
+		s, _ := w.WriteString(str[last:i])
+		size += s
+		switch c {
+		case '"', '\\':
+			w.WriteByte('\\')
+			w.WriteByte(c)
+			size += 2
+		case '\n':
+			s, _ = w.WriteString(`\n`)
+			size += s
+		case '\r':
+			s, _ = w.WriteString(`\r`)
+			size += s
+		case '\t':
+			s, _ = w.WriteString(`\t`)
+			size += s
+		default:
+			s, _ = w.WriteString(`\u00`)
+			size += s
+			w.WriteByte(hex[c>>4])
+			w.WriteByte(hex[c&0xf])
+			size += 2
+		}
+		i++
+		last = i
+	}
+	s, _ := w.WriteString(str[last:])
+	size += s
+
+	w.WriteByte('"')
+	return size + 1
+}
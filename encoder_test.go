@@ -0,0 +1,71 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestTextEncoder(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	entry := newEntry(INFO, Fields{"id": 1}, true, "haha %s. en\\en, %d", []interface{}{"eddie", 18})
+	size := TextEncoder{}.Encode(buffer, entry)
+
+	expected := string(entry.timestamp) + " [INFO] haha eddie. en\\en, 18 id=1\n"
+	if expected != buffer.String() {
+		t.Errorf("text encoder output wrong. out: %s", buffer.String())
+	}
+
+	if buffer.Len() != size {
+		t.Errorf("text encoder size wrong. size: %d, len: %d", size, buffer.Len())
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	fields := Fields{"user": "<eddie>", "count": 3, "err": errors.New("oops"), "msg": "shadowed"}
+	entry := newEntry(WARNING, fields, false, "", []interface{}{"tab\tquote\" \x01"})
+	size := JSONEncoder{}.Encode(buffer, entry)
+
+	if buffer.Len() != size {
+		t.Errorf("json encoder size wrong. size: %d, len: %d", size, buffer.Len())
+	}
+
+	if '\n' != buffer.Bytes()[buffer.Len()-1] {
+		t.Error("json line should end with EOL")
+	}
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buffer.Bytes(), &line); nil != err {
+		t.Fatalf("json encoder output invalid. err: %s, out: %s", err.Error(), buffer.String())
+	}
+
+	if "WARN" != line["level"] || "tab\tquote\" \x01" != line["msg"] || entry.Time.Format(JSONTimeFormat) != line["time"] {
+		t.Errorf("json encoder output wrong. out: %s", buffer.String())
+	}
+
+	if "<eddie>" != line["user"] || float64(3) != line["count"] || "oops" != line["err"] || "shadowed" != line["fields.msg"] {
+		t.Errorf("json encoder fields wrong. out: %s", buffer.String())
+	}
+
+	if !bytes.Contains(buffer.Bytes(), []byte(`"<eddie>"`)) {
+		t.Errorf("json encoder should not escape html. out: %s", buffer.String())
+	}
+}
+
+func TestEncoderFromFormat(t *testing.T) {
+	if encoder, err := encoderFromFormat(""); nil != err || (TextEncoder{}) != encoder {
+		t.Error("empty format should be text encoder")
+	}
+
+	if encoder, err := encoderFromFormat(FormatJSON); nil != err || (JSONEncoder{}) != encoder {
+		t.Error("json format should be json encoder")
+	}
+
+	if _, err := encoderFromFormat("xml"); ErrInvalidFormat != err {
+		t.Error("unknown format should be invalid")
+	}
+}
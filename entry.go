@@ -0,0 +1,107 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"fmt"
+	"time"
+)
+
+// Entry is a single logging action handed to an Encoder
+type Entry struct {
+	// Time is when the message is logged, in full precision
+	Time time.Time
+	// Level is the logging level of the message
+	Level LevelType
+	// Fields is the structured fields attached to the message, may be nil
+	Fields Fields
+	// Logger is the name of the writer logging the message, may be empty
+	Logger string
+	// Caller is where the message is logged, nil when unknown
+	Caller *Caller
+	// Encoded is the exact bytes written by the sink. It is only kept for
+	// an EntryHook and only by sinks encoding entries, nil otherwise
+	Encoded []byte
+
+	// encoded asks the sink to keep the bytes written in Encoded
+	encoded bool
+
+	// timestamp is the text prefix of the text layout, preformatted by the
+	// time cache, its precision is one second
+	timestamp []byte
+
+	// format and args given to the logging function, format is only used
+	// when formatted is true
+	format    string
+	args      []interface{}
+	formatted bool
+}
+
+// Caller describes the call site of a logging action
+type Caller struct {
+	// File is the full path of the source file
+	File string
+	// Line is the line number in File
+	Line int
+	// Function is the fully qualified function name
+	Function string
+}
+
+// newEntry create an entry for a logging action at current time
+func newEntry(level LevelType, fields Fields, formatted bool, format string, args []interface{}) *Entry {
+	entry := &Entry{Level: level, Fields: fields, format: format, args: args, formatted: formatted}
+	entry.Time = time.Now()
+	entry.timestamp = timeCache.Format()
+	return entry
+}
+
+// copy return a shallow copy of the entry
+func (entry *Entry) copy() *Entry {
+	copied := *entry
+	return &copied
+}
+
+// KeepEncoded reports whether the sink should keep the bytes written in
+// Encoded, it is true when an EntryHook will receive the entry
+func (entry *Entry) KeepEncoded() bool {
+	return entry.encoded
+}
+
+// hookArgs return args passed to Hook.Fire, formatted messages are passed
+// as a single formatted string
+func (entry *Entry) hookArgs() []interface{} {
+	if entry.formatted {
+		return []interface{}{entry.Message()}
+	}
+	return entry.args
+}
+
+// hookFields return fields passed to FieldsHook, caller is added with
+// CallerKey when known
+func (entry *Entry) hookFields() Fields {
+	if nil == entry.Caller {
+		return entry.Fields
+	}
+	return entry.Fields.merge(Fields{CallerKey: entry.Caller})
+}
+
+// Message return the message of the entry.
+// Args are formatted with format when the entry is logged with the xxxf
+// functions, otherwise they are formatted as fmt.Sprint does.
+func (entry *Entry) Message() string {
+	if entry.formatted {
+		return fmt.Sprintf(entry.format, entry.args...)
+	}
+	return fmt.Sprint(entry.args...)
+}
+
+// writeMessage writes message of the entry to w and return number of bytes
+// written. Formatted messages are written while parsing the format string.
+func (entry *Entry) writeMessage(w Buffer) int {
+	if entry.formatted {
+		return writeFormat(w, entry.format, entry.args...)
+	}
+
+	s, _ := w.WriteString(fmt.Sprint(entry.args...))
+	return s
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// FallbackPolicy decides what to do with a message when the sink can not
+// write it
+type FallbackPolicy int
+
+const (
+	// FallbackDrop drops the message, it is the default policy
+	FallbackDrop FallbackPolicy = iota
+	// FallbackStderr writes the message to stderr
+	FallbackStderr
+	// FallbackRetry reopens the sink and writes the message again, the
+	// message is dropped when it fails again or the sink can not be reopened
+	FallbackRetry
+)
+
+const (
+	// FallbackTypeDrop is drop fallback tag
+	FallbackTypeDrop = "drop"
+	// FallbackTypeStderr is stderr fallback tag
+	FallbackTypeStderr = "stderr"
+	// FallbackTypeRetry is retry fallback tag
+	FallbackTypeRetry = "retry"
+)
+
+// ErrorHandler is called with every error met by a writer, such as failures
+// of writing, flushing or reopening files. It may be called concurrently,
+// and it may log through the same writer, which is never called with locks
+// of the sink held.
+type ErrorHandler func(err error)
+
+// ErrorHandledSink is an optional interface a Sink may implement when it
+// meets errors in background, such as flushing periodically. The writer
+// sets its handler counting errors before the sink is used.
+type ErrorHandledSink interface {
+	SetErrorHandler(handler ErrorHandler)
+}
+
+// ReopenSink is an optional interface a Sink may implement when its target
+// can be reopened, it is used by FallbackRetry.
+type ReopenSink interface {
+	Reopen() error
+}
+
+// FallbackFromString return fallback policy of the given tag, FallbackDrop
+// and false if the tag is unknown
+func FallbackFromString(fallback string) (FallbackPolicy, bool) {
+	switch strings.ToLower(fallback) {
+	case FallbackTypeDrop:
+		return FallbackDrop, true
+	case FallbackTypeStderr:
+		return FallbackStderr, true
+	case FallbackTypeRetry:
+		return FallbackRetry, true
+	}
+	return FallbackDrop, false
+}
+
+// handleError counts err and pass it to the error handler if set
+func (writer *sinkWriter) handleError(err error) {
+	atomic.AddUint64(&writer.errorCount, 1)
+	if handler := writer.errorHandler; nil != handler {
+		handler(err)
+	}
+}
+
+// fallback handles entry which sink failed to write with err
+func (writer *sinkWriter) fallback(entry *Entry, err error) {
+	writer.handleError(err)
+
+	switch writer.fallbackPolicy {
+	case FallbackStderr:
+		writeStderr(entry)
+	case FallbackRetry:
+		sink, ok := writer.sink.(ReopenSink)
+		if !ok {
+			return
+		}
+		if err = sink.Reopen(); nil != err {
+			writer.handleError(err)
+			return
+		}
+		if err = writer.sink.WriteEntry(entry); nil != err {
+			writer.handleError(err)
+		}
+	}
+}
+
+// writeStderr writes entry into stderr with DefaultEncoder, when no writer
+// can take it
+func writeStderr(entry *Entry) {
+	buffer := new(bytes.Buffer)
+	DefaultEncoder.Encode(buffer, entry)
+	os.Stderr.Write(buffer.Bytes())
+}
+
+// SetErrorHandler set handler called with every error met by the writer
+func (writer *sinkWriter) SetErrorHandler(handler ErrorHandler) {
+	writer.errorHandler = handler
+}
+
+// SetFallback set what to do with a message when the sink can not write it
+func (writer *sinkWriter) SetFallback(policy FallbackPolicy) {
+	writer.fallbackPolicy = policy
+}
+
+// Errors return number of errors met by the writer
+func (writer *sinkWriter) Errors() uint64 {
+	return atomic.LoadUint64(&writer.errorCount)
+}
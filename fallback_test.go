@@ -0,0 +1,169 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errBroken = errors.New("broken sink")
+
+// brokenSink fails writing until reopened
+type brokenSink struct {
+	memorySink
+	broken  bool
+	reopens int
+}
+
+func (sink *brokenSink) WriteEntry(entry *Entry) error {
+	if sink.broken {
+		return errBroken
+	}
+	return sink.memorySink.WriteEntry(entry)
+}
+
+func (sink *brokenSink) Reopen() error {
+	sink.reopens++
+	sink.broken = false
+	return nil
+}
+
+// errorCollector keeps every error handled
+type errorCollector struct {
+	errs []error
+	l    sync.Mutex
+}
+
+func (collector *errorCollector) handle(err error) {
+	collector.l.Lock()
+	defer collector.l.Unlock()
+	collector.errs = append(collector.errs, err)
+}
+
+func TestFallbackPolicies(t *testing.T) {
+	// drop
+	sink := &brokenSink{broken: true}
+	writer := NewSinkLogger(sink)
+	collector := new(errorCollector)
+	writer.SetErrorHandler(collector.handle)
+	writer.Info("dropped")
+	if 1 != writer.Errors() || 1 != len(collector.errs) || errBroken != collector.errs[0] || 0 != len(sink.entries) {
+		t.Errorf("drop fallback wrong. errors: %d, handled: %v", writer.Errors(), collector.errs)
+	}
+
+	// retry
+	writer.SetFallback(FallbackRetry)
+	writer.Info("retried")
+	if 2 != writer.Errors() || 1 != sink.reopens || 1 != len(sink.entries) || "retried" != sink.entries[0].Message() {
+		t.Errorf("retry fallback wrong. errors: %d, reopens: %d, entries: %d", writer.Errors(), sink.reopens, len(sink.entries))
+	}
+
+	// stderr
+	r, w, err := os.Pipe()
+	if nil != err {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	sink.broken = true
+	writer.SetFallback(FallbackStderr)
+	writer.Info("to stderr")
+	os.Stderr = stderr
+	w.Close()
+
+	out, _ := ioutil.ReadAll(r)
+	if !strings.HasSuffix(string(out), " to stderr\n") || 3 != writer.Errors() {
+		t.Errorf("stderr fallback wrong. out: %q, errors: %d", out, writer.Errors())
+	}
+}
+
+func TestFileSinkErrors(t *testing.T) {
+	writer, err := NewBaseFileLogger("/dev/full", false)
+	if nil != err {
+		t.Skipf("/dev/full not available. err: %s", err.Error())
+	}
+
+	collector := new(errorCollector)
+	writer.SetErrorHandler(collector.handle)
+
+	writer.Info("no space")
+	writer.flush()
+	if 1 != writer.Errors() || 1 != len(collector.errs) {
+		t.Fatalf("flush error not handled. errors: %d", writer.Errors())
+	}
+
+	// buffer is reset after error, the next message fails again
+	writer.Info("no space again")
+	writer.flush()
+	if 2 != writer.Errors() {
+		t.Errorf("buffer not reset after error. errors: %d", writer.Errors())
+	}
+	writer.Close()
+}
+
+func TestErrorHandlerLogging(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a regular file at path is never replaced by symlink, which fails
+	name := path.Join(dir, "app.log")
+	if err := ioutil.WriteFile(name, []byte("kept\n"), 0644); nil != err {
+		t.Fatal(err)
+	}
+	sink, err := NewFileSink(name, true)
+	if nil != err {
+		t.Fatal(err)
+	}
+	writer := newSinkWriter(sink)
+
+	// handler logs through its own writer
+	handled := 0
+	writer.SetErrorHandler(func(err error) {
+		handled++
+		writer.Errorf("handled: %s", err.Error())
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sink.SetSymlink(true)
+		writer.Info("rotated")
+		writer.Rotate()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("error handler logging through its writer deadlocked")
+	}
+
+	writer.Close()
+	if 2 != handled || !strings.Contains(readFile(t, sink.currentFileName), "handled: ") {
+		t.Errorf("errors not handled. handled: %d", handled)
+	}
+}
+
+func TestConfigFallback(t *testing.T) {
+	config := new(Config)
+	config.Filters = []filter{{Levels: "info", Fallback: "bogus"}}
+	if ErrConfigBadAttributes != config.valid() {
+		t.Error("invalid fallback should fail validation")
+	}
+
+	config.Filters[0].Fallback = "Stderr"
+	if nil != config.valid() {
+		t.Error("valid fallback should pass validation")
+	}
+	if policy, ok := FallbackFromString("retry"); !ok || FallbackRetry != policy {
+		t.Errorf("fallback from string wrong. policy: %d", policy)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	// exit is called by fatal, replaced in tests
+	exit = os.Exit
+
+	// sink writers not closed yet, of every logger, flushed by settle
+	sinkWriters = make(map[*sinkWriter]struct{})
+	// hook pools not closed yet, of every logger, drained by settle
+	hookPools = make(map[*hookPool]struct{})
+	// lock of sinkWriters and hookPools
+	settledLock = new(sync.Mutex)
+)
+
+// registerSinkWriter adds writer to writers flushed by settle
+func registerSinkWriter(writer *sinkWriter) {
+	settledLock.Lock()
+	defer settledLock.Unlock()
+	sinkWriters[writer] = struct{}{}
+}
+
+// unregisterSinkWriter removes writer from writers flushed by settle
+func unregisterSinkWriter(writer *sinkWriter) {
+	settledLock.Lock()
+	defer settledLock.Unlock()
+	delete(sinkWriters, writer)
+}
+
+// registerHookPool adds pool to hook pools drained by settle
+func registerHookPool(pool *hookPool) {
+	settledLock.Lock()
+	defer settledLock.Unlock()
+	hookPools[pool] = struct{}{}
+}
+
+// unregisterHookPool removes pool from hook pools drained by settle
+func unregisterHookPool(pool *hookPool) {
+	settledLock.Lock()
+	defer settledLock.Unlock()
+	delete(hookPools, pool)
+}
+
+// fatal settles every writer after a FATAL message is written, then exits
+// the process with code 1
+func fatal() {
+	settle()
+	exit(1)
+}
+
+// panicking settles every writer after a PANIC message is written, then
+// panics with message
+func panicking(message string) {
+	settle()
+	panic(message)
+}
+
+// settle delivers async hook events queued, flushes every writer and syncs
+// every file, of every logger, before the process exits or panics. Writers
+// are not closed, so that background work of rotated files, such as
+// compressing, is never waited for.
+func settle() {
+	settledLock.Lock()
+	pools := make([]*hookPool, 0, len(hookPools))
+	for pool := range hookPools {
+		pools = append(pools, pool)
+	}
+	writers := make([]*sinkWriter, 0, len(sinkWriters))
+	for writer := range sinkWriters {
+		writers = append(writers, writer)
+	}
+	settledLock.Unlock()
+
+	// hooks may log, they are delivered before flushing
+	for _, pool := range pools {
+		pool.drain()
+	}
+	for _, writer := range writers {
+		writer.flush()
+	}
+
+	fileSinksLock.Lock()
+	sinks := make([]*FileSink, 0, len(fileSinks))
+	for sink := range fileSinks {
+		sinks = append(sinks, sink)
+	}
+	fileSinksLock.Unlock()
+
+	for _, sink := range sinks {
+		if err := sink.sync(); nil != err {
+			sink.handleError(err)
+		}
+	}
+}
+
+// hookAsync decides whether hook of a message at level is called async,
+// hooks of FATAL and PANIC messages are always called before returning
+func hookAsync(async bool, level LevelType) bool {
+	return async && level < FATAL
+}
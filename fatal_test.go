@@ -0,0 +1,183 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFatal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	code := 0
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	name := path.Join(dir, "fatal.log")
+	writer, err := NewBaseFileLogger(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	hook := NewMyHook()
+	writer.SetHook(hook)
+	writer.SetHookAsync(true)
+	writer.SetHookLevel(FATAL)
+
+	writer.Info("before")
+	writer.Fatalf("%s", "crashed")
+
+	if 1 != code {
+		t.Errorf("exit code wrong. code: %d", code)
+	}
+	// hook is called before exiting though async
+	if 1 != hook.Cnt() || FATAL != hook.Level() || "crashed" != hook.Message() {
+		t.Errorf("hook not called before exiting. cnt: %d, level: %s, message: %s", hook.Cnt(), hook.Level(), hook.Message())
+	}
+	if content := readFile(t, name); !strings.Contains(content, "before") || !strings.Contains(content, "[FATAL] crashed") {
+		t.Errorf("logs not flushed before exiting. content: %s", content)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "panic.log")
+	writer, err := NewBaseFileLogger(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	// another writer buffering logs is flushed too
+	other, err := NewBaseFileLogger(path.Join(dir, "other.log"), false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	other.Info("buffered")
+
+	func() {
+		defer func() {
+			if r := recover(); "broken 1" != r {
+				t.Errorf("panic value wrong. value: %v", r)
+			}
+		}()
+		writer.Panic("broken ", 1)
+	}()
+
+	if content := readFile(t, name); !strings.Contains(content, "[PANIC] broken 1") {
+		t.Errorf("logs not flushed before panic. content: %s", content)
+	}
+	if content := readFile(t, path.Join(dir, "other.log")); !strings.Contains(content, "buffered") {
+		t.Errorf("logs of other writer not flushed before panic. content: %s", content)
+	}
+}
+
+func TestFileLoggerFatal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	exit = func(int) {}
+	defer func() { exit = os.Exit }()
+
+	writer, err := NewFileLogger(dir, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	// no files of FATAL and PANIC
+	for _, name := range []string{"fatal.log", "panic.log"} {
+		if _, err := os.Stat(path.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("file of level should not be created. file: %s", name)
+		}
+	}
+	writer.Fatal("crashed")
+	if content := readFile(t, path.Join(dir, "critical.log")); !strings.Contains(content, "[FATAL] crashed") {
+		t.Errorf("fatal not written into critical.log. content: %s", content)
+	}
+}
+
+func TestFatalRouted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	code := 0
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	// no filter of fatal level, as configs written before it
+	name := path.Join(dir, "error.log")
+	configFile := path.Join(dir, "fatal.xml")
+	config := fmt.Sprintf(`<blog4go minlevel="info">
+	<filter levels="error,critical">
+		<file path="%s"></file>
+	</filter>
+</blog4go>`, name)
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); nil != err {
+		t.Fatal(err)
+	}
+	writer, err := NewLoggerFromConfigAsFile(configFile)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	// archiving in background is never waited for
+	archived := make(chan struct{})
+	other, err := NewFileSink(path.Join(dir, "other.log"), false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	other.SetRotateHandler(func(oldPath string, newPath string, reason RotateReason) error {
+		<-archived
+		return nil
+	})
+	otherWriter := newSinkWriter(other)
+	defer otherWriter.Close()
+	defer close(archived)
+	otherWriter.Info("rotated")
+	otherWriter.Rotate()
+	otherWriter.Info("buffered")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer.Fatal("crashed")
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fatal waited for background work")
+	}
+
+	if 1 != code {
+		t.Errorf("exit code wrong. code: %d", code)
+	}
+	if content := readFile(t, name); !strings.Contains(content, "[FATAL] crashed") {
+		t.Errorf("fatal message not routed to writers of lower level. content: %s", content)
+	}
+	if content := readFile(t, path.Join(dir, "other.log")); !strings.Contains(content, "buffered") {
+		t.Errorf("logs of other writer not flushed. content: %s", content)
+	}
+}
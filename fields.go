@@ -0,0 +1,209 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fields is a set of key/value pairs attached to logging actions, so that
+// values like request id or user id stay parseable in the written logs
+type Fields map[string]interface{}
+
+// fieldsFromPairs converts alternating keys and values into Fields.
+// Keys which are not strings are formatted with fmt.Sprint, a trailing key
+// without value is kept with a nil value.
+func fieldsFromPairs(pairs ...interface{}) Fields {
+	fields := make(Fields, (len(pairs)+1)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			key = fmt.Sprint(pairs[i])
+		}
+
+		var value interface{}
+		if i+1 < len(pairs) {
+			value = pairs[i+1]
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// merge returns a new Fields holding fields and other.
+// Values in other override values in fields with the same key.
+func (fields Fields) merge(other Fields) Fields {
+	merged := make(Fields, len(fields)+len(other))
+	for key, value := range fields {
+		merged[key] = value
+	}
+	for key, value := range other {
+		merged[key] = value
+	}
+	return merged
+}
+
+// keys return keys of fields in lexical order
+func (fields Fields) keys() []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeTo writes fields in " key=value" form ordered by key, values which
+// contain spaces, quotes or equal signs are quoted.
+// It returns number of bytes written.
+func (fields Fields) writeTo(w io.StringWriter) (size int) {
+	for _, key := range fields.keys() {
+		value := fmt.Sprint(fields[key])
+		if "" == value || strings.ContainsAny(value, " =\"\t\r\n") {
+			value = strconv.Quote(value)
+		}
+
+		s, _ := w.WriteString(" ")
+		size += s
+		s, _ = w.WriteString(key)
+		size += s
+		s, _ = w.WriteString("=")
+		size += s
+		s, _ = w.WriteString(value)
+		size += s
+	}
+	return
+}
+
+// fieldsWriter is a Writer derived from another Writer by WithFields or
+// With. It attaches its fields to every logging action and passes it to
+// the parent writer, every other setting like level, hook and logrotate
+// is shared with the parent writer.
+type fieldsWriter struct {
+	Writer
+
+	fields Fields
+}
+
+// newFieldsWriter derive a fieldsWriter from writer, fields are copied so
+// that later changes of the given map do not affect the derived writer
+func newFieldsWriter(writer Writer, fields Fields) *fieldsWriter {
+	return &fieldsWriter{Writer: writer, fields: Fields(nil).merge(fields)}
+}
+
+// WithFields derive a writer with fields added to fields of this writer
+func (writer *fieldsWriter) WithFields(fields Fields) Writer {
+	return newFieldsWriter(writer.Writer, writer.fields.merge(fields))
+}
+
+// With derive a writer with alternating keys and values added to fields of
+// this writer
+func (writer *fieldsWriter) With(pairs ...interface{}) Writer {
+	return writer.WithFields(fieldsFromPairs(pairs...))
+}
+
+func (writer *fieldsWriter) write(level LevelType, fields Fields, args ...interface{}) {
+	if 0 != len(fields) {
+		fields = writer.fields.merge(fields)
+	} else {
+		fields = writer.fields
+	}
+	writer.Writer.write(level, fields, args...)
+}
+
+func (writer *fieldsWriter) writef(level LevelType, fields Fields, format string, args ...interface{}) {
+	if 0 != len(fields) {
+		fields = writer.fields.merge(fields)
+	} else {
+		fields = writer.fields
+	}
+	writer.Writer.writef(level, fields, format, args...)
+}
+
+// Trace trace
+func (writer *fieldsWriter) Trace(args ...interface{}) {
+	writer.Writer.write(TRACE, writer.fields, args...)
+}
+
+// Tracef tracef
+func (writer *fieldsWriter) Tracef(format string, args ...interface{}) {
+	writer.Writer.writef(TRACE, writer.fields, format, args...)
+}
+
+// Debug debug
+func (writer *fieldsWriter) Debug(args ...interface{}) {
+	writer.Writer.write(DEBUG, writer.fields, args...)
+}
+
+// Debugf debugf
+func (writer *fieldsWriter) Debugf(format string, args ...interface{}) {
+	writer.Writer.writef(DEBUG, writer.fields, format, args...)
+}
+
+// Info info
+func (writer *fieldsWriter) Info(args ...interface{}) {
+	writer.Writer.write(INFO, writer.fields, args...)
+}
+
+// Infof infof
+func (writer *fieldsWriter) Infof(format string, args ...interface{}) {
+	writer.Writer.writef(INFO, writer.fields, format, args...)
+}
+
+// Warn warn
+func (writer *fieldsWriter) Warn(args ...interface{}) {
+	writer.Writer.write(WARNING, writer.fields, args...)
+}
+
+// Warnf warnf
+func (writer *fieldsWriter) Warnf(format string, args ...interface{}) {
+	writer.Writer.writef(WARNING, writer.fields, format, args...)
+}
+
+// Error error
+func (writer *fieldsWriter) Error(args ...interface{}) {
+	writer.Writer.write(ERROR, writer.fields, args...)
+}
+
+// Errorf errorf
+func (writer *fieldsWriter) Errorf(format string, args ...interface{}) {
+	writer.Writer.writef(ERROR, writer.fields, format, args...)
+}
+
+// Critical critical
+func (writer *fieldsWriter) Critical(args ...interface{}) {
+	writer.Writer.write(CRITICAL, writer.fields, args...)
+}
+
+// Criticalf criticalf
+func (writer *fieldsWriter) Criticalf(format string, args ...interface{}) {
+	writer.Writer.writef(CRITICAL, writer.fields, format, args...)
+}
+
+// Fatal fatal
+func (writer *fieldsWriter) Fatal(args ...interface{}) {
+	writer.Writer.write(FATAL, writer.fields, args...)
+	fatal()
+}
+
+// Fatalf fatalf
+func (writer *fieldsWriter) Fatalf(format string, args ...interface{}) {
+	writer.Writer.writef(FATAL, writer.fields, format, args...)
+	fatal()
+}
+
+// Panic panic
+func (writer *fieldsWriter) Panic(args ...interface{}) {
+	writer.Writer.write(PANIC, writer.fields, args...)
+	panicking(fmt.Sprint(args...))
+}
+
+// Panicf panicf
+func (writer *fieldsWriter) Panicf(format string, args ...interface{}) {
+	writer.Writer.writef(PANIC, writer.fields, format, args...)
+	panicking(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type MyFieldsHook struct {
+	fields Fields
+	args   []interface{}
+
+	l *sync.RWMutex
+}
+
+func (hook *MyFieldsHook) Fire(level LevelType, args ...interface{}) {
+}
+
+func (hook *MyFieldsHook) FireWithFields(level LevelType, fields Fields, args ...interface{}) {
+	hook.l.Lock()
+	defer hook.l.Unlock()
+	hook.fields = fields
+	hook.args = args
+}
+
+func (hook *MyFieldsHook) Fields() Fields {
+	hook.l.RLock()
+	defer hook.l.RUnlock()
+	return hook.fields
+}
+
+func TestFieldsFromPairs(t *testing.T) {
+	fields := fieldsFromPairs("request_id", 12, 3, "three", "dangling")
+	if 12 != fields["request_id"] || "three" != fields["3"] {
+		t.Errorf("fields from pairs wrong. fields: %+v", fields)
+	}
+
+	if value, ok := fields["dangling"]; !ok || nil != value {
+		t.Errorf("dangling key should be kept with nil value. fields: %+v", fields)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	err := NewFileWriter("/tmp", false)
+	defer func() {
+		Close()
+
+		// clean logs
+		_, err = exec.Command("/bin/sh", "-c", "/bin/rm /tmp/*.log*").Output()
+		if nil != err {
+			t.Errorf("clean files failed. err: %s", err.Error())
+		}
+	}()
+	if nil != err {
+		t.Fatal(err.Error())
+	}
+
+	hook := &MyFieldsHook{l: new(sync.RWMutex)}
+	SetHook(hook)
+	SetHookAsync(false)
+
+	fields := Fields{"request_id": 12}
+	logger := WithFields(fields).With("user", "eddie chen")
+	// later changes of given map should not affect derived writer
+	fields["request_id"] = 13
+
+	logger.Info("hello")
+	logger.Infof("hello %s", "world")
+	logger.Debug("filtered")
+	SetLevel(INFO)
+	logger.Debug("filtered")
+	Info("no fields")
+	Flush()
+
+	content, err := ioutil.ReadFile("/tmp/info.log")
+	if nil != err {
+		t.Fatal(err.Error())
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if 3 != len(lines) {
+		t.Fatalf("lines written wrong. content: %s", content)
+	}
+
+	if !strings.HasSuffix(lines[0], "hello request_id=12 user=\"eddie chen\"") {
+		t.Errorf("fields not written. line: %s", lines[0])
+	}
+
+	if !strings.HasSuffix(lines[1], "hello world request_id=12 user=\"eddie chen\"") {
+		t.Errorf("fields not written with formatted message. line: %s", lines[1])
+	}
+
+	if !strings.HasSuffix(lines[2], "no fields") {
+		t.Errorf("fields written without WithFields. line: %s", lines[2])
+	}
+
+	// hook receives fields as data
+	if nil != hook.Fields() {
+		t.Errorf("hook should not receive fields. fields: %+v", hook.Fields())
+	}
+	logger.Warn("warn")
+	if 12 != hook.Fields()["request_id"] || "eddie chen" != hook.Fields()["user"] {
+		t.Errorf("hook receive wrong fields. fields: %+v", hook.Fields())
+	}
+}
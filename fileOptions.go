@@ -0,0 +1,190 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	// DefaultFileMode is the default mode of log files
+	DefaultFileMode os.FileMode = 0644
+	// DefaultDirMode is the default mode of directories created for log files
+	DefaultDirMode os.FileMode = 0755
+)
+
+var (
+	// ErrSharedNotSupported show that file locks of shared mode are not
+	// supported on the platform
+	ErrSharedNotSupported = errors.New("Shared log files are not supported on this platform.")
+)
+
+// FileOptions are settings of files opened by a file sink. Modes are
+// subject to umask of the process.
+type FileOptions struct {
+	// Mode of log files created, DefaultFileMode if 0
+	Mode os.FileMode
+
+	// Mkdir creates missing parent directories of log files
+	Mkdir bool
+	// DirMode of directories created, DefaultDirMode if 0
+	DirMode os.FileMode
+
+	// Chown changes owner of log files and directories created to Uid and
+	// Gid, -1 keeps the one unchanged
+	Chown bool
+	Uid   int
+	Gid   int
+
+	// Shared coordinates logrotate with other processes appending to the
+	// same file, by an advisory lock on a hidden lock file beside it.
+	// Processes not rotating the file reopen it before writing.
+	Shared bool
+}
+
+// DefaultFileOptions return options of files opened by NewFileSink
+func DefaultFileOptions() FileOptions {
+	return FileOptions{Mode: DefaultFileMode, DirMode: DefaultDirMode, Uid: -1, Gid: -1}
+}
+
+// withDefaults fills modes not set with defaults
+func (options FileOptions) withDefaults() FileOptions {
+	if 0 == options.Mode {
+		options.Mode = DefaultFileMode
+	}
+	if 0 == options.DirMode {
+		options.DirMode = DefaultDirMode
+	}
+	return options
+}
+
+// openFile opens fileName for appending with options, missing directories
+// are created if needed
+func openFile(fileName string, options FileOptions) (*os.File, error) {
+	if options.Mkdir {
+		if err := mkdirAll(filepath.Dir(fileName), options); nil != err {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, options.Mode)
+	if nil != err {
+		return nil, err
+	}
+
+	if options.Chown {
+		if err = file.Chown(options.Uid, options.Gid); nil != err {
+			file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// lockFileName return name of the lock file of fileName in shared mode,
+// it is hidden so that it never matches rotated files
+func lockFileName(fileName string) string {
+	return filepath.Join(filepath.Dir(fileName), "."+filepath.Base(fileName)+".lock")
+}
+
+// mkdirAll creates dir and its missing parents with DirMode of options,
+// owner of every directory created is changed if needed
+func mkdirAll(dir string, options FileOptions) error {
+	if info, err := os.Stat(dir); nil == err {
+		if !info.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: dir, Err: os.ErrExist}
+		}
+		return nil
+	}
+
+	if parent := filepath.Dir(dir); parent != dir {
+		if err := mkdirAll(parent, options); nil != err {
+			return err
+		}
+	}
+
+	if err := os.Mkdir(dir, options.DirMode); nil != err {
+		// created by others meanwhile
+		if info, statErr := os.Stat(dir); nil == statErr && info.IsDir() {
+			return nil
+		}
+		return err
+	}
+
+	if options.Chown {
+		return os.Chown(dir, options.Uid, options.Gid)
+	}
+	return nil
+}
+
+// fileAttrs are attributes of file and rotatefile elements in config file
+// about files opened, such as
+// <file path="/var/log/app/pii.log" mode="0600" mkdir="true" uid="1000" shared="true"></file>
+type fileAttrs struct {
+	// mode of log files in octal
+	Mode string `xml:"mode,attr"`
+	// create missing directories
+	Mkdir bool `xml:"mkdir,attr"`
+	// mode of directories created in octal
+	DirMode string `xml:"dirMode,attr"`
+	// owner of log files and directories created
+	Uid string `xml:"uid,attr"`
+	Gid string `xml:"gid,attr"`
+	// file appended by several processes
+	Shared bool `xml:"shared,attr"`
+}
+
+// options return FileOptions of attributes
+func (attrs fileAttrs) options() (FileOptions, error) {
+	options := DefaultFileOptions()
+	options.Mkdir = attrs.Mkdir
+	options.Shared = attrs.Shared
+
+	var err error
+	if options.Mode, err = parseFileMode(attrs.Mode, DefaultFileMode); nil != err {
+		return options, err
+	}
+	if options.DirMode, err = parseFileMode(attrs.DirMode, DefaultDirMode); nil != err {
+		return options, err
+	}
+
+	if "" != attrs.Uid || "" != attrs.Gid {
+		options.Chown = true
+		if options.Uid, err = parseID(attrs.Uid); nil != err {
+			return options, err
+		}
+		if options.Gid, err = parseID(attrs.Gid); nil != err {
+			return options, err
+		}
+	}
+	return options, nil
+}
+
+// parseFileMode parse mode in octal, such as "0600", defaultMode if empty
+func parseFileMode(mode string, defaultMode os.FileMode) (os.FileMode, error) {
+	if "" == mode {
+		return defaultMode, nil
+	}
+
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if nil != err || perm > uint64(os.ModePerm) {
+		return 0, ErrConfigBadAttributes
+	}
+	return os.FileMode(perm), nil
+}
+
+// parseID parse uid or gid, -1 if empty
+func parseID(id string) (int, error) {
+	if "" == id {
+		return -1, nil
+	}
+
+	n, err := strconv.Atoi(id)
+	if nil != err || n < 0 {
+		return 0, ErrConfigBadAttributes
+	}
+	return n, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+)
+
+func TestFileOptionsFromConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// missing directories are created, files are owned by current user
+	name := path.Join(dir, "pii", "2016", "pii.log")
+	configFile := path.Join(dir, "options.xml")
+	config := fmt.Sprintf(`<blog4go minlevel="info">
+	<filter levels="info">
+		<file path="%s" mode="0600" mkdir="true" dirMode="0700" uid="%d" gid="%d"></file>
+	</filter>
+</blog4go>`, name, os.Getuid(), os.Getgid())
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); nil != err {
+		t.Fatal(err)
+	}
+
+	writer, err := NewLoggerFromConfigAsFile(configFile)
+	if nil != err {
+		t.Fatal(err)
+	}
+	writer.Info("pii")
+	writer.Close()
+
+	info, err := os.Stat(name)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 0600 != info.Mode().Perm() {
+		t.Errorf("file mode wrong. mode: %s", info.Mode())
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		t.Errorf("file owner wrong. uid: %d", stat.Uid)
+	}
+
+	for _, created := range []string{path.Join(dir, "pii"), path.Join(dir, "pii", "2016")} {
+		if info, err := os.Stat(created); nil != err || 0700 != info.Mode().Perm() {
+			t.Errorf("directory created wrong. dir: %s, info: %v, err: %v", created, info, err)
+		}
+	}
+
+	// missing directories fail without mkdir
+	if _, err := NewFileSink(path.Join(dir, "missing", "a.log"), false); nil == err {
+		t.Error("missing directory should fail without mkdir")
+	}
+}
+
+func TestFileAttrs(t *testing.T) {
+	options, err := fileAttrs{}.options()
+	if nil != err || DefaultFileOptions() != options {
+		t.Errorf("default options wrong. options: %v, err: %v", options, err)
+	}
+
+	options, err = fileAttrs{Mode: "640", Gid: "20"}.options()
+	if nil != err || 0640 != options.Mode || !options.Chown || -1 != options.Uid || 20 != options.Gid {
+		t.Errorf("options wrong. options: %v, err: %v", options, err)
+	}
+
+	if options, err = (fileAttrs{Shared: true}).options(); nil != err || !options.Shared {
+		t.Errorf("shared not set. options: %v, err: %v", options, err)
+	}
+
+	for _, attrs := range []fileAttrs{{Mode: "0999"}, {DirMode: "rwx"}, {Mode: "01777"}, {Uid: "root"}, {Gid: "-2"}} {
+		if _, err := attrs.options(); ErrConfigBadAttributes != err {
+			t.Errorf("attributes should be invalid. attrs: %v", attrs)
+		}
+	}
+
+	config := new(Config)
+	config.Filters = []filter{{Levels: "info", RotateFile: rotateFile{Path: "/tmp/a.log", Type: TypeSizeBaseRotate, fileAttrs: fileAttrs{Mode: "888"}}}}
+	if ErrConfigBadAttributes != config.valid() {
+		t.Error("invalid mode should fail validation")
+	}
+}
@@ -8,7 +8,7 @@ import (
 	"strings"
 )
 
-// NewFileWriter initialize a file writer
+// NewFileWriter initialize the default writer as a file writer
 // baseDir must be base directory of log files
 // rotate determine if it will logrotate
 func NewFileWriter(baseDir string, rotate bool) (err error) {
@@ -18,18 +18,41 @@ func NewFileWriter(baseDir string, rotate bool) (err error) {
 		return ErrAlreadyInit
 	}
 
+	fileWriter, err := NewFileLogger(baseDir, rotate)
+	if nil != err {
+		return err
+	}
+
+	blog = fileWriter
+	return
+}
+
+// NewFileLogger create an independent file writer, every level is logged
+// into its own file named after the level, like info.log. FATAL and PANIC
+// are logged into critical.log.
+// baseDir must be base directory of log files
+// rotate determine if it will logrotate
+func NewFileLogger(baseDir string, rotate bool) (Writer, error) {
 	fileWriter := new(MultiWriter)
 	fileWriter.level = DEBUG
 	fileWriter.closed = false
+	fileWriter.hookPool = newHookPool()
+	fileWriter.flushLevel = LevelType(-1)
+	fileWriter.flushInterval = DefaultFlushInterval
 
-	fileWriter.writers = make(map[LevelType]Writer)
+	fileWriter.writers = make(map[LevelType][]Writer)
 	for _, level := range Levels {
+		// FATAL and PANIC are routed to CRITICAL
+		if level > CRITICAL {
+			break
+		}
 		fileName := fmt.Sprintf("%s.log", strings.ToLower(level.String()))
-		writer, err := newBaseFileWriter(path.Join(baseDir, fileName), rotate)
+		writer, err := NewBaseFileLogger(path.Join(baseDir, fileName), rotate)
 		if nil != err {
-			return err
+			fileWriter.Close()
+			return nil, err
 		}
-		fileWriter.writers[level] = writer
+		fileWriter.addWriter(writer, level)
 	}
 
 	// log hook
@@ -37,6 +60,5 @@ func NewFileWriter(baseDir string, rotate bool) (err error) {
 	fileWriter.hookLevel = DEBUG
 	fileWriter.hookAsync = true
 
-	blog = fileWriter
-	return
+	return fileWriter, nil
 }
@@ -0,0 +1,38 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+//go:build windows || plan9 || js || wasip1
+// +build windows plan9 js wasip1
+
+package blog4go
+
+import (
+	"os"
+)
+
+// fileLock is not supported on this platform, shared mode fails
+type fileLock struct{}
+
+// openFileLock return ErrSharedNotSupported
+func openFileLock(path string, mode os.FileMode) (*fileLock, error) {
+	return nil, ErrSharedNotSupported
+}
+
+func (l *fileLock) lock() error {
+	return ErrSharedNotSupported
+}
+
+func (l *fileLock) rlock() error {
+	return ErrSharedNotSupported
+}
+
+func (l *fileLock) unlock() error {
+	return ErrSharedNotSupported
+}
+
+func (l *fileLock) claim(path string) (bool, error) {
+	return false, ErrSharedNotSupported
+}
+
+func (l *fileLock) close() error {
+	return nil
+}
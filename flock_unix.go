@@ -0,0 +1,79 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package blog4go
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// fileLock is an advisory lock on a lock file shared by processes. Locks
+// of the same fileLock are not exclusive in the process, every user in the
+// process opens its own one.
+type fileLock struct {
+	file *os.File
+}
+
+// openFileLock opens lock file at path, it is created if not exists
+func openFileLock(path string, mode os.FileMode) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, mode)
+	if nil != err {
+		return nil, err
+	}
+	return &fileLock{file: file}, nil
+}
+
+// lock acquires exclusive lock, a shared lock held is converted
+func (l *fileLock) lock() error {
+	return l.flock(syscall.LOCK_EX)
+}
+
+// rlock acquires shared lock
+func (l *fileLock) rlock() error {
+	return l.flock(syscall.LOCK_SH)
+}
+
+// unlock releases lock held
+func (l *fileLock) unlock() error {
+	return l.flock(syscall.LOCK_UN)
+}
+
+// claim records path in the lock file, it return false if path is the one
+// recorded by others. It must be called with exclusive lock held.
+func (l *fileLock) claim(path string) (bool, error) {
+	recorded := make([]byte, len(path)+1)
+	n, err := l.file.ReadAt(recorded, 0)
+	if nil != err && io.EOF != err {
+		return false, err
+	}
+	if path == string(recorded[:n]) {
+		return false, nil
+	}
+
+	if err = l.file.Truncate(0); nil != err {
+		return false, err
+	}
+	if _, err = l.file.WriteAt([]byte(path), 0); nil != err {
+		return false, err
+	}
+	return true, nil
+}
+
+// flock retries when interrupted by signals
+func (l *fileLock) flock(how int) error {
+	for {
+		err := syscall.Flock(int(l.file.Fd()), how)
+		if syscall.EINTR != err {
+			return err
+		}
+	}
+}
+
+// close closes lock file, lock held is released
+func (l *fileLock) close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package blog4go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSharedFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// sinks sharing the file behave like processes, as locks of the lock
+	// file opened by every sink exclude each other
+	name := path.Join(dir, "shared.log")
+	options := DefaultFileOptions()
+	options.Shared = true
+	writers := make([]Writer, 0, 2)
+	for i := 0; i < 2; i++ {
+		sink, err := NewFileSinkWithOptions(name, false, options)
+		if nil != err {
+			t.Fatal(err)
+		}
+		sink.SetRotateSize(200)
+		sink.SetRetentions(1000)
+		writers = append(writers, newSinkWriter(sink))
+	}
+
+	var wg sync.WaitGroup
+	for i, writer := range writers {
+		wg.Add(1)
+		go func(i int, writer Writer) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				writer.Infof("writer-%d-%d", i, j)
+			}
+		}(i, writer)
+	}
+	wg.Wait()
+	for _, writer := range writers {
+		writer.Close()
+	}
+
+	// every line is written once into a file never exceeding rotate size
+	matches, _ := filepath.Glob(name + "*")
+	written := make(map[string]int)
+	for _, match := range matches {
+		if info, err := os.Stat(match); nil != err || info.Size() > 200 {
+			t.Errorf("file rotated wrong. file: %s, info: %v, err: %v", match, info, err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(readFile(t, match)), "\n") {
+			fields := strings.Fields(line)
+			written[fields[len(fields)-1]]++
+		}
+	}
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 100; j++ {
+			if msg := fmt.Sprintf("writer-%d-%d", i, j); 1 != written[msg] {
+				t.Errorf("line not written once. line: %s, count: %d", msg, written[msg])
+			}
+		}
+	}
+	if _, err := os.Stat(lockFileName(name)); nil != err {
+		t.Errorf("lock file not found. err: %s", err.Error())
+	}
+}
+
+func TestSharedFileSinkTimeRotated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// every sink rotates the file by time, only one archives it
+	name := path.Join(dir, "shared.log")
+	options := DefaultFileOptions()
+	options.Shared = true
+	archived := make(map[string]int)
+	var l sync.Mutex
+	writers := make([]Writer, 0, 2)
+	for i := 0; i < 2; i++ {
+		sink, err := NewFileSinkWithOptions(name, true, options)
+		if nil != err {
+			t.Fatal(err)
+		}
+		sink.SetRotatePeriod(time.Second)
+		sink.SetCompress(true)
+		sink.SetRotateHandler(func(oldPath string, newPath string, reason RotateReason) error {
+			l.Lock()
+			defer l.Unlock()
+			archived[newPath]++
+			return nil
+		})
+		writers = append(writers, newSinkWriter(sink))
+	}
+
+	for deadline := time.Now().Add(2500 * time.Millisecond); time.Now().Before(deadline); {
+		for _, writer := range writers {
+			writer.Info("shared")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, writer := range writers {
+		writer.Close()
+	}
+
+	if 0 == len(archived) {
+		t.Error("file rotated by time not archived")
+	}
+	for newPath, count := range archived {
+		if 1 != count || !strings.HasSuffix(newPath, CompressSuffix) {
+			t.Errorf("file not archived once. file: %s, count: %d", newPath, count)
+		}
+	}
+}
+
+func TestFileLockClaim(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	lockFile := path.Join(dir, ".claim.log.lock")
+	first, err := openFileLock(lockFile, DefaultFileMode)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer first.close()
+	second, err := openFileLock(lockFile, DefaultFileMode)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer second.close()
+
+	for _, c := range []struct {
+		lock    *fileLock
+		path    string
+		claimed bool
+	}{
+		{first, "claim.log.2016-01-01", true},
+		{second, "claim.log.2016-01-01", false},
+		{second, "claim.log.2016-01-02", true},
+		{first, "claim.log.2016-01-0", true},
+	} {
+		c.lock.lock()
+		claimed, err := c.lock.claim(c.path)
+		c.lock.unlock()
+		if nil != err || c.claimed != claimed {
+			t.Errorf("claim wrong. path: %s, expected: %t, got: %t, err: %v", c.path, c.claimed, claimed, err)
+		}
+	}
+}
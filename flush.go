@@ -0,0 +1,114 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"strings"
+	"time"
+)
+
+// SyncPolicy decides when logs flushed are synced to disk with fsync, so
+// that they survive a crash of the system
+type SyncPolicy int
+
+const (
+	// SyncNever leaves syncing to the system, it is the default policy
+	SyncNever SyncPolicy = iota
+	// SyncOnRotate syncs a file before it is rotated or closed
+	SyncOnRotate
+	// SyncOnFlush syncs a file every time it is flushed
+	SyncOnFlush
+	// SyncInterval syncs a file every interval given
+	SyncInterval
+)
+
+const (
+	// SyncTypeNever is never sync tag
+	SyncTypeNever = "never"
+	// SyncTypeRotate is sync on rotate tag
+	SyncTypeRotate = "rotate"
+	// SyncTypeFlush is sync on flush tag
+	SyncTypeFlush = "flush"
+
+	// DefaultFlushInterval is the default interval of flushing buffered logs
+	// in background
+	DefaultFlushInterval = time.Second
+)
+
+// FlushedSink is an optional interface a Sink may implement when it
+// flushes buffered logs in background periodically.
+type FlushedSink interface {
+	SetFlushInterval(interval time.Duration)
+	FlushInterval() time.Duration
+}
+
+// SyncedSink is an optional interface a Sink may implement when it syncs
+// its target to disk.
+type SyncedSink interface {
+	SetSyncPolicy(policy SyncPolicy, interval time.Duration)
+	SyncPolicy() SyncPolicy
+}
+
+// SyncFromString return sync policy of the given tag, a duration such as
+// "5s" is SyncInterval with the interval. SyncNever and false are returned
+// if the tag is unknown.
+func SyncFromString(sync string) (SyncPolicy, time.Duration, bool) {
+	switch strings.ToLower(sync) {
+	case SyncTypeNever:
+		return SyncNever, 0, true
+	case SyncTypeRotate:
+		return SyncOnRotate, 0, true
+	case SyncTypeFlush:
+		return SyncOnFlush, 0, true
+	}
+
+	interval, err := time.ParseDuration(sync)
+	if nil != err || interval < time.Second {
+		return SyncNever, 0, false
+	}
+	return SyncInterval, interval, true
+}
+
+// FlushLevel get level at or above which messages are flushed at once
+func (writer *sinkWriter) FlushLevel() LevelType {
+	return writer.flushLevel
+}
+
+// SetFlushLevel set level at or above which messages are flushed at once
+// after written, such as ERROR, so that they are not lost in buffer when
+// the process crashes. Invalid level, the default, flushes nothing at once.
+func (writer *sinkWriter) SetFlushLevel(level LevelType) {
+	writer.flushLevel = level
+}
+
+// FlushInterval get interval of flushing in background if sink is a
+// FlushedSink
+func (writer *sinkWriter) FlushInterval() time.Duration {
+	if sink, ok := writer.sink.(FlushedSink); ok {
+		return sink.FlushInterval()
+	}
+	return 0
+}
+
+// SetFlushInterval set interval of flushing in background if sink is a
+// FlushedSink
+func (writer *sinkWriter) SetFlushInterval(interval time.Duration) {
+	if sink, ok := writer.sink.(FlushedSink); ok {
+		sink.SetFlushInterval(interval)
+	}
+}
+
+// SyncPolicy get sync policy if sink is a SyncedSink
+func (writer *sinkWriter) SyncPolicy() SyncPolicy {
+	if sink, ok := writer.sink.(SyncedSink); ok {
+		return sink.SyncPolicy()
+	}
+	return SyncNever
+}
+
+// SetSyncPolicy set sync policy if sink is a SyncedSink
+func (writer *sinkWriter) SetSyncPolicy(policy SyncPolicy, interval time.Duration) {
+	if sink, ok := writer.sink.(SyncedSink); ok {
+		sink.SetSyncPolicy(policy, interval)
+	}
+}
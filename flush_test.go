@@ -0,0 +1,107 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncFromString(t *testing.T) {
+	for _, c := range []struct {
+		sync     string
+		policy   SyncPolicy
+		interval time.Duration
+		ok       bool
+	}{
+		{"never", SyncNever, 0, true},
+		{"Rotate", SyncOnRotate, 0, true},
+		{"flush", SyncOnFlush, 0, true},
+		{"5s", SyncInterval, 5 * time.Second, true},
+		{"10ms", SyncNever, 0, false},
+		{"sometimes", SyncNever, 0, false},
+	} {
+		policy, interval, ok := SyncFromString(c.sync)
+		if c.policy != policy || c.interval != interval || c.ok != ok {
+			t.Errorf("sync policy wrong. sync: %s, policy: %d, interval: %s, ok: %t", c.sync, policy, interval, ok)
+		}
+	}
+}
+
+func TestFlushLevel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "flushed.log")
+	writer, err := NewBaseFileLogger(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	writer.SetFlushLevel(ERROR)
+	writer.SetSyncPolicy(SyncOnFlush, 0)
+
+	// messages below flush level stay in buffer
+	writer.Info("buffered")
+	if content := readFile(t, name); "" != content {
+		t.Errorf("message below flush level flushed. content: %s", content)
+	}
+
+	writer.Error("crashed")
+	if content := readFile(t, name); !strings.Contains(content, "buffered") || !strings.Contains(content, "crashed") {
+		t.Errorf("message at flush level not flushed. content: %s", content)
+	}
+	if ERROR != writer.FlushLevel() || SyncOnFlush != writer.SyncPolicy() {
+		t.Errorf("settings wrong. flush level: %s, sync policy: %d", writer.FlushLevel(), writer.SyncPolicy())
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "interval.log")
+	configFile := path.Join(dir, "flush.xml")
+	config := fmt.Sprintf(`<blog4go minlevel="info">
+	<filter levels="info,error" flushInterval="20ms" flushLevel="critical" sync="5s">
+		<file path="%s"></file>
+	</filter>
+</blog4go>`, name)
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); nil != err {
+		t.Fatal(err)
+	}
+
+	writer, err := NewLoggerFromConfigAsFile(configFile)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	writer.Info("soon")
+	time.Sleep(200 * time.Millisecond)
+	if content := readFile(t, name); !strings.Contains(content, "soon") {
+		t.Errorf("message not flushed in interval. content: %s", content)
+	}
+
+	// invalid attributes fail validation
+	for _, invalid := range []filter{{FlushInterval: "soon"}, {FlushLevel: "loud"}, {Sync: "sometimes"}} {
+		config := new(Config)
+		invalid.Levels = "info"
+		invalid.File = file{Path: name}
+		config.Filters = []filter{invalid}
+		if ErrConfigBadAttributes != config.valid() {
+			t.Errorf("invalid filter passed validation. filter: %v", invalid)
+		}
+	}
+}
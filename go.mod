@@ -0,0 +1,3 @@
+module github.com/YoungPioneers/blog4go
+
+go 1.20
@@ -2,6 +2,11 @@
 
 package blog4go
 
+import (
+	"sync"
+	"sync/atomic"
+)
+
 // Hook Interface determine types of functions should be declared and
 // implemented when user offers user defined function call before every
 // logging action end.
@@ -12,3 +17,246 @@ package blog4go
 type Hook interface {
 	Fire(level LevelType, args ...interface{})
 }
+
+// FieldsHook is an optional interface a Hook may implement to receive the
+// fields attached with WithFields or With as data. When a hook implements
+// FieldsHook, FireWithFields is called instead of Fire.
+// fields is nil when no fields are attached to that logging action, the
+// *Caller of the logging action is added with CallerKey when captured.
+type FieldsHook interface {
+	FireWithFields(level LevelType, fields Fields, args ...interface{})
+}
+
+// EntryHook interface is a hook receiving the whole Entry of a logging
+// action: time, level, message, fields, caller, logger name and the exact
+// bytes encoded by the sink. It is set with SetEntryHook.
+// FireEntry must not modify the entry, it is shared with other hooks.
+type EntryHook interface {
+	FireEntry(entry *Entry)
+}
+
+// hookAdapter adapts a Hook to EntryHook, the hook is called with args as
+// before
+type hookAdapter struct {
+	hook Hook
+}
+
+// FireEntry calls Fire, or FireWithFields if hook is a FieldsHook
+func (adapter hookAdapter) FireEntry(entry *Entry) {
+	if fieldsHook, ok := adapter.hook.(FieldsHook); ok {
+		fieldsHook.FireWithFields(entry.Level, entry.hookFields(), entry.hookArgs()...)
+		return
+	}
+	adapter.hook.Fire(entry.Level, entry.hookArgs()...)
+}
+
+// adaptHook return hook as an EntryHook, nil if hook is nil
+func adaptHook(hook Hook) EntryHook {
+	if nil == hook {
+		return nil
+	}
+	return hookAdapter{hook: hook}
+}
+
+// hookEncoded determines whether hook needs encoded bytes of entries, only
+// hooks set with SetEntryHook read them
+func hookEncoded(hook EntryHook) bool {
+	if nil == hook {
+		return false
+	}
+	_, adapted := hook.(hookAdapter)
+	return !adapted
+}
+
+// fireHook calls hook for a logging action, it is queued into pool if async
+func fireHook(hook EntryHook, pool *hookPool, async bool, entry *Entry) {
+	if async {
+		pool.fire(hook, entry)
+		return
+	}
+	hook.FireEntry(entry)
+}
+
+// HookQueuePolicy decides what to do when the queue of async hooks is full
+type HookQueuePolicy int
+
+const (
+	// HookQueueBlock blocks the logging action until the queue has room
+	HookQueueBlock HookQueuePolicy = iota
+	// HookQueueDropNewest drops the hook event of the logging action
+	HookQueueDropNewest
+	// HookQueueDropOldest drops the oldest hook event waiting in the queue
+	HookQueueDropOldest
+)
+
+const (
+	// DefaultHookQueueSize is the default number of async hook events
+	// waiting for delivery
+	DefaultHookQueueSize = 1024
+	// DefaultHookWorkers is the default number of goroutines delivering
+	// async hook events
+	DefaultHookWorkers = 1
+)
+
+// hookEvent is a pending call of hook
+type hookEvent struct {
+	hook  EntryHook
+	entry *Entry
+}
+
+// hookPool delivers async hook events with a bounded queue and a fixed
+// number of workers, workers are started when the first event is fired
+type hookPool struct {
+	// queue size and number of workers
+	size    int
+	workers int
+	// what to do when queue is full
+	policy HookQueuePolicy
+
+	queue chan hookEvent
+	// wait for workers delivering events left in queue
+	wg *sync.WaitGroup
+
+	// number of events dropped, accessed atomically
+	dropped uint64
+
+	// lock of queue, held exclusively when queue is replaced or closed
+	lock   *sync.RWMutex
+	closed bool
+}
+
+// newHookPool create a hook pool with default settings
+func newHookPool() *hookPool {
+	pool := new(hookPool)
+	pool.size = DefaultHookQueueSize
+	pool.workers = DefaultHookWorkers
+	pool.policy = HookQueueBlock
+	pool.wg = new(sync.WaitGroup)
+	pool.lock = new(sync.RWMutex)
+	registerHookPool(pool)
+	return pool
+}
+
+// fire queues a call of hook with entry, the hook is called at once when
+// pool is closed
+func (pool *hookPool) fire(hook EntryHook, entry *Entry) {
+	pool.lock.RLock()
+	if nil == pool.queue && !pool.closed {
+		pool.lock.RUnlock()
+		pool.start()
+		pool.lock.RLock()
+	}
+	defer pool.lock.RUnlock()
+
+	if pool.closed {
+		hook.FireEntry(entry)
+		return
+	}
+
+	event := hookEvent{hook: hook, entry: entry}
+	switch pool.policy {
+	case HookQueueDropNewest:
+		select {
+		case pool.queue <- event:
+		default:
+			atomic.AddUint64(&pool.dropped, 1)
+		}
+	case HookQueueDropOldest:
+		for {
+			select {
+			case pool.queue <- event:
+				return
+			default:
+			}
+
+			// make room by dropping the oldest event
+			select {
+			case <-pool.queue:
+				atomic.AddUint64(&pool.dropped, 1)
+			default:
+			}
+		}
+	default:
+		pool.queue <- event
+	}
+}
+
+// start create the queue and workers if not started
+func (pool *hookPool) start() {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	if nil != pool.queue || pool.closed {
+		return
+	}
+
+	pool.queue = make(chan hookEvent, pool.size)
+	for i := 0; i < pool.workers; i++ {
+		pool.wg.Add(1)
+		go pool.work(pool.queue)
+	}
+}
+
+// work delivers events in queue until it is closed
+func (pool *hookPool) work(queue chan hookEvent) {
+	defer pool.wg.Done()
+	for event := range queue {
+		event.hook.FireEntry(event.entry)
+	}
+}
+
+// stop close the queue and wait for workers delivering events left in it,
+// pool.lock must be held
+func (pool *hookPool) stop() {
+	if nil == pool.queue {
+		return
+	}
+
+	close(pool.queue)
+	pool.queue = nil
+	pool.wg.Wait()
+}
+
+// setQueue set queue size and number of workers, events queued are
+// delivered before workers are restarted with the new settings
+func (pool *hookPool) setQueue(size int, workers int) {
+	if size < 1 || workers < 1 {
+		return
+	}
+
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	pool.stop()
+	pool.size = size
+	pool.workers = workers
+}
+
+// setPolicy set what to do when queue is full
+func (pool *hookPool) setPolicy(policy HookQueuePolicy) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	pool.policy = policy
+}
+
+// Dropped return number of events dropped
+func (pool *hookPool) Dropped() uint64 {
+	return atomic.LoadUint64(&pool.dropped)
+}
+
+// close wait for events queued to be delivered, events fired after close
+// are delivered synchronously
+func (pool *hookPool) close() {
+	unregisterHookPool(pool)
+
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	pool.stop()
+	pool.closed = true
+}
+
+// drain wait for events queued to be delivered, workers are started again
+// by the next event fired
+func (pool *hookPool) drain() {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	pool.stop()
+}
@@ -4,7 +4,11 @@ package blog4go
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -110,3 +114,159 @@ func TestHook(t *testing.T) {
 		t.Errorf("clean files failed. err: %s", err.Error())
 	}
 }
+
+// MyEntryHook keeps every entry received
+type MyEntryHook struct {
+	entries []*Entry
+	l       sync.Mutex
+}
+
+func (hook *MyEntryHook) FireEntry(entry *Entry) {
+	hook.l.Lock()
+	defer hook.l.Unlock()
+	hook.entries = append(hook.entries, entry)
+}
+
+func TestEntryHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewBaseFileLogger(path.Join(dir, "entry.log"), false)
+	if nil != err {
+		t.Fatalf("create logger failed. err: %s", err.Error())
+	}
+
+	hook := new(MyEntryHook)
+	writer.SetEntryHook(hook)
+	writer.SetHookAsync(false)
+	writer.SetLevel(INFO)
+	writer.SetName("app")
+	writer.SetReportCaller(true)
+
+	writer.Debug("dropped")
+	line := nextLine()
+	writer.With("user", "eddie").Infof("hello %s", "hook")
+	writer.Close()
+
+	if 1 != len(hook.entries) {
+		t.Fatalf("entry hook called %d times", len(hook.entries))
+	}
+	entry := hook.entries[0]
+	if INFO != entry.Level || "hello hook" != entry.Message() || "eddie" != entry.Fields["user"] || "app" != entry.Logger {
+		t.Errorf("entry wrong. entry: %+v", entry)
+	}
+	if nil == entry.Caller || line != entry.Caller.Line || entry.Time.IsZero() {
+		t.Errorf("entry caller or time wrong. caller: %v, time: %v", entry.Caller, entry.Time)
+	}
+	if content := readFile(t, path.Join(dir, "entry.log")); content != string(entry.Encoded) {
+		t.Errorf("encoded bytes differ from file. encoded: %q, file: %q", entry.Encoded, content)
+	}
+}
+
+func TestEntryHookMultiWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewFileLogger(dir, false)
+	if nil != err {
+		t.Fatalf("create logger failed. err: %s", err.Error())
+	}
+
+	hook := new(MyEntryHook)
+	legacy := NewMyHook()
+	writer.SetEntryHook(hook)
+	writer.SetHookAsync(false)
+	writer.Info("first")
+	writer.SetHook(legacy)
+	writer.Infof("second %d", 2)
+	writer.Close()
+
+	if 1 != len(hook.entries) || "first" != hook.entries[0].Message() {
+		t.Fatalf("entry hook not replaced by hook. entries: %d", len(hook.entries))
+	}
+	if !strings.HasPrefix(readFile(t, path.Join(dir, "info.log")), string(hook.entries[0].Encoded)) || 0 == len(hook.entries[0].Encoded) {
+		t.Errorf("encoded bytes wrong. encoded: %q", hook.entries[0].Encoded)
+	}
+	if 1 != legacy.Cnt() || "second 2" != legacy.Message() {
+		t.Errorf("adapted hook wrong. cnt: %d, message: %s", legacy.Cnt(), legacy.Message())
+	}
+}
+
+// blockedHook blocks every call until released
+type blockedHook struct {
+	MyEntryHook
+	release chan bool
+}
+
+func (hook *blockedHook) FireEntry(entry *Entry) {
+	<-hook.release
+	hook.MyEntryHook.FireEntry(entry)
+}
+
+func (hook *blockedHook) messages() []string {
+	hook.l.Lock()
+	defer hook.l.Unlock()
+	var messages []string
+	for _, entry := range hook.entries {
+		messages = append(messages, entry.Message())
+	}
+	return messages
+}
+
+func TestHookPoolPolicies(t *testing.T) {
+	for _, policy := range []HookQueuePolicy{HookQueueDropNewest, HookQueueDropOldest} {
+		hook := &blockedHook{release: make(chan bool)}
+		pool := newHookPool()
+		pool.setQueue(2, 1)
+		pool.setPolicy(policy)
+
+		// the first event is taken by the worker, the queue holds two more
+		pool.fire(hook, newEntry(INFO, nil, false, "", []interface{}{0}))
+		time.Sleep(10 * time.Millisecond)
+		for i := 1; i <= 4; i++ {
+			pool.fire(hook, newEntry(INFO, nil, false, "", []interface{}{i}))
+		}
+		if 2 != pool.Dropped() {
+			t.Errorf("dropped count wrong. policy: %d, dropped: %d", policy, pool.Dropped())
+		}
+
+		close(hook.release)
+		pool.close()
+
+		expected := "[0 1 2]"
+		if HookQueueDropOldest == policy {
+			expected = "[0 3 4]"
+		}
+		if messages := fmt.Sprint(hook.messages()); expected != messages {
+			t.Errorf("hook events wrong. policy: %d, events: %s", policy, messages)
+		}
+	}
+}
+
+func TestHookPoolCloseWaits(t *testing.T) {
+	writer := NewSinkLogger(new(memorySink))
+	hook := &blockedHook{release: make(chan bool)}
+	writer.SetEntryHook(hook)
+	writer.SetHookQueue(16, 2)
+	writer.SetHookQueuePolicy(HookQueueBlock)
+
+	for i := 0; i < 10; i++ {
+		writer.Info(i)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(hook.release)
+	}()
+	writer.Close()
+
+	if 10 != len(hook.messages()) || 0 != writer.HookDropped() {
+		t.Errorf("close should wait for hook events. delivered: %d, dropped: %d", len(hook.messages()), writer.HookDropped())
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPatternTimeFormat is the time format of %d without argument
+	DefaultPatternTimeFormat = "2006/01/02:15:04:05"
+	// DefaultLoggerName is what %c writes for writers without a name
+	DefaultLoggerName = "root"
+
+	// unknownCaller is written for caller conversions when caller is unknown
+	unknownCaller = "?"
+)
+
+var (
+	// ErrInvalidPattern invalid layout pattern
+	ErrInvalidPattern = errors.New("Invalid layout pattern")
+
+	// pid and hostname of the process, looked up once
+	pid      = strconv.Itoa(os.Getpid())
+	hostname = lookupHostname()
+)
+
+// layoutOp writes one part of a log line and returns number of bytes written
+type layoutOp func(w Buffer, entry *Entry) int
+
+// PatternEncoder encodes entries with a log4j style conversion pattern, for
+// example "%d{2006-01-02T15:04:05.000} %p %c %F:%L - %m%n".
+// The pattern is compiled once into a sequence of write operations, so the
+// message is still written while parsing the format string.
+//
+// Conversions supported:
+//
+//	%d        time in DefaultPatternTimeFormat
+//	%d{fmt}   time in Go time layout fmt
+//	%p        level
+//	%c        logger name, DefaultLoggerName if not named
+//	%F        file name of the caller, without directory
+//	%L        line number of the caller
+//	%M        function name of the caller
+//	%P        process id
+//	%H        hostname
+//	%t        goroutine id
+//	%X        all fields as " key=value" like the text layout does
+//	%X{key}   value of a single field
+//	%m        message
+//	%n        newline
+//	%%        percent sign
+//
+// Caller conversions write "?" when caller of the message is unknown,
+// filters of config file with them report caller without caller="true".
+// A newline is appended when the pattern does not end with one.
+type PatternEncoder struct {
+	pattern string
+	ops     []layoutOp
+
+	// whether pattern has caller conversions
+	caller bool
+}
+
+// NewPatternEncoder compiles pattern into a PatternEncoder
+func NewPatternEncoder(pattern string) (*PatternEncoder, error) {
+	ops, caller, err := compilePattern(pattern)
+	if nil != err {
+		return nil, err
+	}
+
+	return &PatternEncoder{pattern: pattern, ops: ops, caller: caller}, nil
+}
+
+// Pattern return the conversion pattern of the encoder
+func (encoder *PatternEncoder) Pattern() string {
+	return encoder.pattern
+}
+
+// Encode writes entry with the compiled pattern
+func (encoder *PatternEncoder) Encode(w Buffer, entry *Entry) (size int) {
+	for _, op := range encoder.ops {
+		size += op(w, entry)
+	}
+	return
+}
+
+// compilePattern compiles pattern into a sequence of layoutOps, caller
+// reports whether any of them writes caller
+func compilePattern(pattern string) (ops []layoutOp, caller bool, err error) {
+	// literal text not compiled into ops yet
+	var literal []byte
+
+	for i := 0; i < len(pattern); i++ {
+		if PLACEHOLDER != pattern[i] {
+			literal = append(literal, pattern[i])
+			continue
+		}
+
+		i++
+		if i >= len(pattern) {
+			return nil, false, ErrInvalidPattern
+		}
+		verb := pattern[i]
+
+		// optional {argument} of the conversion
+		var arg string
+		if i+1 < len(pattern) && '{' == pattern[i+1] {
+			end := strings.IndexByte(pattern[i+2:], '}')
+			if end < 0 {
+				return nil, false, ErrInvalidPattern
+			}
+			arg = pattern[i+2 : i+2+end]
+			i += 2 + end
+		}
+
+		switch verb {
+		case PLACEHOLDER:
+			literal = append(literal, PLACEHOLDER)
+			continue
+		case 'n':
+			literal = append(literal, EOL)
+			continue
+		}
+
+		op, err := newLayoutOp(verb, arg)
+		if nil != err {
+			return nil, false, err
+		}
+		if 'F' == verb || 'L' == verb || 'M' == verb {
+			caller = true
+		}
+
+		if 0 != len(literal) {
+			ops = append(ops, literalOp(string(literal)))
+			literal = nil
+		}
+		ops = append(ops, op)
+	}
+
+	if 0 == len(literal) || EOL != literal[len(literal)-1] {
+		literal = append(literal, EOL)
+	}
+	ops = append(ops, literalOp(string(literal)))
+
+	return ops, caller, nil
+}
+
+// newLayoutOp create the layoutOp of a conversion
+func newLayoutOp(verb byte, arg string) (layoutOp, error) {
+	switch verb {
+	case 'd':
+		layout := arg
+		if "" == layout {
+			layout = DefaultPatternTimeFormat
+		}
+		return func(w Buffer, entry *Entry) int {
+			var scratch [64]byte
+			s, _ := w.Write(entry.Time.AppendFormat(scratch[:0], layout))
+			return s
+		}, nil
+	case 'p':
+		return func(w Buffer, entry *Entry) int {
+			s, _ := w.WriteString(entry.Level.String())
+			return s
+		}, nil
+	case 'c':
+		return func(w Buffer, entry *Entry) int {
+			name := entry.Logger
+			if "" == name {
+				name = DefaultLoggerName
+			}
+			s, _ := w.WriteString(name)
+			return s
+		}, nil
+	case 'F':
+		return func(w Buffer, entry *Entry) int {
+			if nil == entry.Caller {
+				s, _ := w.WriteString(unknownCaller)
+				return s
+			}
+			s, _ := w.WriteString(filepath.Base(entry.Caller.File))
+			return s
+		}, nil
+	case 'L':
+		return func(w Buffer, entry *Entry) int {
+			if nil == entry.Caller {
+				s, _ := w.WriteString(unknownCaller)
+				return s
+			}
+			s, _ := w.WriteString(strconv.Itoa(entry.Caller.Line))
+			return s
+		}, nil
+	case 'M':
+		return func(w Buffer, entry *Entry) int {
+			if nil == entry.Caller {
+				s, _ := w.WriteString(unknownCaller)
+				return s
+			}
+			s, _ := w.WriteString(entry.Caller.Function)
+			return s
+		}, nil
+	case 'P':
+		return literalOp(pid), nil
+	case 'H':
+		return literalOp(hostname), nil
+	case 't':
+		return func(w Buffer, entry *Entry) int {
+			s, _ := w.WriteString(goroutineID())
+			return s
+		}, nil
+	case 'X':
+		if "" == arg {
+			return func(w Buffer, entry *Entry) int {
+				return entry.Fields.writeTo(w)
+			}, nil
+		}
+		return func(w Buffer, entry *Entry) int {
+			value, ok := entry.Fields[arg]
+			if !ok {
+				return 0
+			}
+			s, _ := w.WriteString(fmt.Sprint(value))
+			return s
+		}, nil
+	case 'm':
+		return func(w Buffer, entry *Entry) int {
+			return entry.writeMessage(w)
+		}, nil
+	}
+
+	return nil, ErrInvalidPattern
+}
+
+// literalOp create a layoutOp writing str as it is
+func literalOp(str string) layoutOp {
+	return func(w Buffer, entry *Entry) int {
+		s, _ := w.WriteString(str)
+		return s
+	}
+}
+
+// goroutineID return id of current goroutine parsed from its stack header
+// "goroutine 123 [running]:"
+func goroutineID() string {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	header := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(header, ' '); i > 0 {
+		return string(header[:i])
+	}
+	return unknownCaller
+}
+
+// lookupHostname return hostname of the machine, "localhost" if unknown
+func lookupHostname() string {
+	name, err := os.Hostname()
+	if nil != err || "" == name {
+		return "localhost"
+	}
+	return name
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPatternEncoder(t *testing.T) {
+	encoder, err := NewPatternEncoder("%d{2006-01-02T15:04:05} %p %c %F:%L %M [%P@%H] 100%% - %m%X {%X{id}}%n")
+	if nil != err {
+		t.Fatal(err.Error())
+	}
+
+	entry := newEntry(ERROR, Fields{"id": 7, "user": "eddie"}, true, "haha %s. en\\en, %d", []interface{}{"eddie", 18})
+	entry.Time = time.Date(2016, 10, 17, 8, 30, 0, 0, time.UTC)
+	entry.Logger = "audit"
+
+	buffer := new(bytes.Buffer)
+	size := encoder.Encode(buffer, entry)
+
+	expected := fmt.Sprintf("2016-10-17T08:30:00 ERROR audit ?:? ? [%d@%s] 100%% - haha eddie. en\\en, 18 id=7 user=eddie {7}\n", os.Getpid(), hostname)
+	if expected != buffer.String() {
+		t.Errorf("pattern encoder output wrong. out: %s, expected: %s", buffer.String(), expected)
+	}
+
+	if buffer.Len() != size {
+		t.Errorf("pattern encoder size wrong. size: %d, len: %d", size, buffer.Len())
+	}
+
+	// caller and default logger name
+	entry.Caller = &Caller{File: "/go/src/app/main.go", Line: 12, Function: "main.main"}
+	entry.Logger = ""
+	encoder, _ = NewPatternEncoder("%c %F:%L %M")
+	buffer.Reset()
+	encoder.Encode(buffer, entry)
+	if "root main.go:12 main.main\n" != buffer.String() {
+		t.Errorf("pattern encoder caller output wrong. out: %s", buffer.String())
+	}
+}
+
+func TestPatternEncoderLogged(t *testing.T) {
+	encoder, err := NewPatternEncoder("%d{05.000} %F:%L")
+	if nil != err {
+		t.Fatal(err.Error())
+	}
+
+	// milliseconds of messages logged in the same second differ
+	lines := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		entry := newEntry(INFO, nil, false, "", nil)
+		line := nextLine()
+		entry.Caller = captureCaller(0)
+
+		buffer := new(bytes.Buffer)
+		encoder.Encode(buffer, entry)
+		if !strings.HasSuffix(buffer.String(), fmt.Sprintf(" layout_test.go:%d\n", line)) {
+			t.Errorf("caller file name wrong. out: %s", buffer.String())
+		}
+		lines[buffer.String()] = true
+		time.Sleep(120 * time.Millisecond)
+	}
+	if 3 != len(lines) {
+		t.Errorf("time of messages not in full precision. lines: %v", lines)
+	}
+}
+
+func TestPatternEncoderGoroutine(t *testing.T) {
+	encoder, _ := NewPatternEncoder("%t")
+	entry := newEntry(INFO, nil, false, "", nil)
+
+	first := new(bytes.Buffer)
+	encoder.Encode(first, entry)
+
+	second := new(bytes.Buffer)
+	done := make(chan bool)
+	go func() {
+		encoder.Encode(second, entry)
+		done <- true
+	}()
+	<-done
+
+	if "?\n" == first.String() || first.String() == second.String() {
+		t.Errorf("goroutine id wrong. first: %s, second: %s", first.String(), second.String())
+	}
+}
+
+func TestInvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"%", "%d{2006", "%z", "%m %Q%n"} {
+		if _, err := NewPatternEncoder(pattern); ErrInvalidPattern != err {
+			t.Errorf("pattern should be invalid. pattern: %s", pattern)
+		}
+	}
+}
+
+func BenchmarkPatternEncoder(b *testing.B) {
+	encoder, _ := NewPatternEncoder("%d %p %c - %m%n")
+	buffer := new(bytes.Buffer)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buffer.Reset()
+		encoder.Encode(buffer, newEntry(DEBUG, nil, true, "haha %s. en\\en, always %d and %f", []interface{}{"eddie", 18, 3.1415}))
+	}
+}
@@ -26,6 +26,10 @@ const (
 	ERROR
 	// CRITICAL critical level
 	CRITICAL
+	// FATAL fatal level, the process exits after logging
+	FATAL
+	// PANIC panic level, it panics after logging
+	PANIC
 	// UNKNOWN unknown level
 	UNKNOWN = "UNKNOWN"
 
@@ -49,53 +53,48 @@ const (
 	YELLOW = 33
 	// BLUE blue color
 	BLUE = 34
+	// MAGENTA magenta color
+	MAGENTA = 35
 	// GRAY gray color
 	GRAY = 37
 )
 
 var (
 	// LevelStrings is string present for each level
-	LevelStrings = [...]string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "CRITICAL"}
+	LevelStrings = [...]string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "CRITICAL", "FATAL", "PANIC"}
 
 	// StringLevels is map, level strings to levels
-	StringLevels = map[string]LevelType{"TRACE": TRACE, "DEBUG": DEBUG, "INFO": INFO, "WARN": WARNING, "ERROR": ERROR, "CRITICAL": CRITICAL}
+	StringLevels = map[string]LevelType{"TRACE": TRACE, "DEBUG": DEBUG, "INFO": INFO, "WARN": WARNING, "ERROR": ERROR, "CRITICAL": CRITICAL, "FATAL": FATAL, "PANIC": PANIC}
 
 	// Levels is a slice consist of all levels
-	Levels = [...]LevelType{TRACE, DEBUG, INFO, WARNING, ERROR, CRITICAL}
+	Levels = [...]LevelType{TRACE, DEBUG, INFO, WARNING, ERROR, CRITICAL, FATAL, PANIC}
 
 	// Prefix is preformatted level prefix string
 	// help reduce string formatted burden in realtime logging
 	Prefix = make(map[LevelType]string)
+
+	// coloredPrefix is preformatted level prefix string in colored format
+	coloredPrefix = make(map[LevelType]string)
 )
 
 func init() {
-	initPrefix(false) // preformat level prefix string
+	initPrefix() // preformat level prefix string
 }
 
-// initPrefix is designed to preformat level prefix string for each level.
-// colored decide whether preformat in colored format or not.
-// if colored is true, preformat level prefix string in colored format
-func initPrefix(colored bool) {
-	if colored {
-		Prefix[TRACE] = fmt.Sprintf(ColoredPrefixFormat, GRAY, TRACE.String())
-		Prefix[DEBUG] = fmt.Sprintf(ColoredPrefixFormat, GREEN, DEBUG.String())
-		Prefix[INFO] = fmt.Sprintf(ColoredPrefixFormat, BLUE, INFO.String())
-		Prefix[WARNING] = fmt.Sprintf(ColoredPrefixFormat, YELLOW, WARNING.String())
-		Prefix[ERROR] = fmt.Sprintf(ColoredPrefixFormat, RED, ERROR.String())
-		Prefix[CRITICAL] = fmt.Sprintf(ColoredPrefixFormat, RED, CRITICAL.String())
-	} else {
-		Prefix[TRACE] = fmt.Sprintf(PrefixFormat, TRACE.String())
-		Prefix[DEBUG] = fmt.Sprintf(PrefixFormat, DEBUG.String())
-		Prefix[INFO] = fmt.Sprintf(PrefixFormat, INFO.String())
-		Prefix[WARNING] = fmt.Sprintf(PrefixFormat, WARNING.String())
-		Prefix[ERROR] = fmt.Sprintf(PrefixFormat, ERROR.String())
-		Prefix[CRITICAL] = fmt.Sprintf(PrefixFormat, CRITICAL.String())
+// initPrefix is designed to preformat level prefix string for each level,
+// in both plain and colored format. Prefixes are never changed after, so
+// that writers colored or not never affect each other.
+func initPrefix() {
+	colors := map[LevelType]int{TRACE: GRAY, DEBUG: GREEN, INFO: BLUE, WARNING: YELLOW, ERROR: RED, CRITICAL: RED, FATAL: MAGENTA, PANIC: MAGENTA}
+	for _, level := range Levels {
+		Prefix[level] = fmt.Sprintf(PrefixFormat, level.String())
+		coloredPrefix[level] = fmt.Sprintf(ColoredPrefixFormat, colors[level], level.String())
 	}
 }
 
 // valid determines whether a Level instance is valid or not
 func (level LevelType) valid() bool {
-	if TRACE > level || CRITICAL < level {
+	if TRACE > level || PANIC < level {
 		return false
 	}
 	return true
@@ -109,8 +108,12 @@ func (level LevelType) String() string {
 	return LevelStrings[level]
 }
 
-// prefix return formatted prefix string associate with a Level instance
-func (level LevelType) prefix() string {
+// prefix return formatted prefix string associate with a Level instance,
+// in colored format if colored
+func (level LevelType) prefix(colored bool) string {
+	if colored {
+		return coloredPrefix[level]
+	}
 	return Prefix[level]
 }
 
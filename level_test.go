@@ -41,39 +41,49 @@ func TestLevelStringFormat(t *testing.T) {
 		t.Error("CRITICAL Level to wrong string format.")
 	}
 
-	if " [CRITICAL] " != CRITICAL.prefix() {
+	if " [CRITICAL] " != CRITICAL.prefix(false) {
 		t.Error("CRITICAL Level to wrong prefix string format.")
 	}
 
+	if "FATAL" != FATAL.String() || " [FATAL] " != FATAL.prefix(false) {
+		t.Error("FATAL Level to wrong string format.")
+	}
+
+	if "PANIC" != PANIC.String() || " [PANIC] " != PANIC.prefix(false) {
+		t.Error("PANIC Level to wrong string format.")
+	}
+
 	if "UNKNOWN" != LevelType(-1).String() {
 		t.Error("Wrong Level to wrong string format.")
 	}
 
-	initPrefix(true)
-
-	if " [\x1b[37mTRACE\x1b[0m] " != TRACE.prefix() {
+	if " [\x1b[37mTRACE\x1b[0m] " != TRACE.prefix(true) {
 		t.Error("TRACE Level with color to wrong prefix string format.")
 	}
 
-	if " [\x1b[32mDEBUG\x1b[0m] " != DEBUG.prefix() {
+	if " [\x1b[32mDEBUG\x1b[0m] " != DEBUG.prefix(true) {
 		t.Error("DEBUG Level with color to wrong prefix string format.")
 	}
 
-	if " [\x1b[34mINFO\x1b[0m] " != INFO.prefix() {
+	if " [\x1b[34mINFO\x1b[0m] " != INFO.prefix(true) {
 		t.Error("INFO Level with color to wrong prefix string format.")
 	}
 
-	if " [\x1b[33mWARN\x1b[0m] " != WARNING.prefix() {
+	if " [\x1b[33mWARN\x1b[0m] " != WARNING.prefix(true) {
 		t.Error("WARN Level with color to wrong prefix string format.")
 	}
 
-	if " [\x1b[31mERROR\x1b[0m] " != ERROR.prefix() {
+	if " [\x1b[31mERROR\x1b[0m] " != ERROR.prefix(true) {
 		t.Error("ERROR Level with color to wrong prefix string format.")
 	}
 
-	if " [\x1b[31mCRITICAL\x1b[0m] " != CRITICAL.prefix() {
+	if " [\x1b[31mCRITICAL\x1b[0m] " != CRITICAL.prefix(true) {
 		t.Error("CRITICAL Level with color to wrong prefix string format.")
 	}
+
+	if " [\x1b[35mFATAL\x1b[0m] " != FATAL.prefix(true) || " [\x1b[35mPANIC\x1b[0m] " != PANIC.prefix(true) {
+		t.Error("FATAL and PANIC Level with color to wrong prefix string format.")
+	}
 }
 
 func TestStringToLevel(t *testing.T) {
@@ -82,6 +92,11 @@ func TestStringToLevel(t *testing.T) {
 		t.Errorf("String to level failed. str: %s", str)
 	}
 
+	str = "panic"
+	if PANIC != LevelFromString(str) {
+		t.Errorf("String to level failed. str: %s", str)
+	}
+
 	str = "something"
 	if LevelFromString(str).valid() {
 		t.Errorf("String to level invalid. str: %s", str)
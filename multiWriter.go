@@ -5,6 +5,7 @@ package blog4go
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 var (
@@ -20,26 +21,59 @@ var (
 type MultiWriter struct {
 	level LevelType
 
-	// file writers
-	writers map[LevelType]Writer
+	// writers of each level, every writer in the list receives messages of
+	// that level in the order they were added
+	writers map[LevelType][]Writer
+	// filters holds every distinct writer in the order they were added,
+	// used for flushing and closing each writer only once
+	filters []Writer
 
 	colored bool
 
 	closed bool
 
+	// name of the writer, written by %c of PatternEncoder
+	name string
+
+	// caller of logging actions
+	callerReported bool
+	callerLevel    LevelType
+
 	// configuration about user defined logging hook
 	// actual hook instance
-	hook Hook
+	hook EntryHook
 	// hook is called when message level exceed level of logging action
 	hookLevel LevelType
 	// it determines whether hook is called async, default true
 	hookAsync bool
+	// delivers async hook events
+	hookPool *hookPool
 
 	// logrotate
-	timeRotated bool
-	retentions  int64
-	rotateSize  int64
-	rotateLines int
+	timeRotated  bool
+	rotatePeriod time.Duration
+	retentions   int64
+	maxAge       time.Duration
+	maxBytes     int64
+	rotateSize   int64
+	rotateLines  int
+
+	// flushing and syncing
+	flushLevel    LevelType
+	flushInterval time.Duration
+	syncPolicy    SyncPolicy
+}
+
+// addWriter appends writer to the writer list of every given level
+func (writer *MultiWriter) addWriter(filter Writer, levels ...LevelType) {
+	if nil == writer.writers {
+		writer.writers = make(map[LevelType][]Writer)
+	}
+
+	for _, level := range levels {
+		writer.writers[level] = append(writer.writers[level], filter)
+	}
+	writer.filters = append(writer.filters, filter)
 }
 
 // TimeRotated get timeRotated
@@ -50,11 +84,24 @@ func (writer *MultiWriter) TimeRotated() bool {
 // SetTimeRotated toggle time base logrotate
 func (writer *MultiWriter) SetTimeRotated(timeRotated bool) {
 	writer.timeRotated = timeRotated
-	for _, fileWriter := range writer.writers {
+	for _, fileWriter := range writer.filters {
 		fileWriter.SetTimeRotated(timeRotated)
 	}
 }
 
+// RotatePeriod get period of time base logrotate
+func (writer *MultiWriter) RotatePeriod() time.Duration {
+	return writer.rotatePeriod
+}
+
+// SetRotatePeriod set period of time base logrotate
+func (writer *MultiWriter) SetRotatePeriod(period time.Duration) {
+	writer.rotatePeriod = period
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetRotatePeriod(period)
+	}
+}
+
 // Retentions get retentions
 func (writer *MultiWriter) Retentions() int64 {
 	return writer.retentions
@@ -67,11 +114,37 @@ func (writer *MultiWriter) SetRetentions(retentions int64) {
 	}
 
 	writer.retentions = retentions
-	for _, fileWriter := range writer.writers {
+	for _, fileWriter := range writer.filters {
 		fileWriter.SetRetentions(retentions)
 	}
 }
 
+// MaxAge get max age of rotated files
+func (writer *MultiWriter) MaxAge() time.Duration {
+	return writer.maxAge
+}
+
+// SetMaxAge set max age of rotated files of every writer
+func (writer *MultiWriter) SetMaxAge(maxAge time.Duration) {
+	writer.maxAge = maxAge
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetMaxAge(maxAge)
+	}
+}
+
+// MaxBytes get max total size of logs
+func (writer *MultiWriter) MaxBytes() int64 {
+	return writer.maxBytes
+}
+
+// SetMaxBytes set max total size of logs of every writer
+func (writer *MultiWriter) SetMaxBytes(maxBytes int64) {
+	writer.maxBytes = maxBytes
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetMaxBytes(maxBytes)
+	}
+}
+
 // RotateSize get rotateSize
 func (writer *MultiWriter) RotateSize() int64 {
 	return writer.rotateSize
@@ -80,7 +153,7 @@ func (writer *MultiWriter) RotateSize() int64 {
 // SetRotateSize set size when logroatate
 func (writer *MultiWriter) SetRotateSize(rotateSize int64) {
 	writer.rotateSize = rotateSize
-	for _, fileWriter := range writer.writers {
+	for _, fileWriter := range writer.filters {
 		fileWriter.SetRotateSize(rotateSize)
 	}
 }
@@ -93,7 +166,7 @@ func (writer *MultiWriter) RotateLines() int {
 // SetRotateLines set line number when logrotate
 func (writer *MultiWriter) SetRotateLines(rotateLines int) {
 	writer.rotateLines = rotateLines
-	for _, fileWriter := range writer.writers {
+	for _, fileWriter := range writer.filters {
 		fileWriter.SetRotateLines(rotateLines)
 	}
 }
@@ -106,13 +179,75 @@ func (writer *MultiWriter) Colored() bool {
 // SetColored set logging color
 func (writer *MultiWriter) SetColored(colored bool) {
 	writer.colored = colored
-	for _, fileWriter := range writer.writers {
+	for _, fileWriter := range writer.filters {
 		fileWriter.SetColored(colored)
 	}
 }
 
+// SetEncoder set encoder formatting log lines of every writer
+func (writer *MultiWriter) SetEncoder(encoder Encoder) {
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetEncoder(encoder)
+	}
+}
+
+// Name get name of the writer
+func (writer *MultiWriter) Name() string {
+	return writer.name
+}
+
+// SetName set name of every writer
+func (writer *MultiWriter) SetName(name string) {
+	writer.name = name
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetName(name)
+	}
+}
+
+// ReportCaller get whether caller of logging actions is captured
+func (writer *MultiWriter) ReportCaller() bool {
+	return writer.callerReported
+}
+
+// SetReportCaller set whether caller of logging actions is captured by
+// every writer
+func (writer *MultiWriter) SetReportCaller(reportCaller bool) {
+	writer.callerReported = reportCaller
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetReportCaller(reportCaller)
+	}
+}
+
+// CallerLevel get lowest level which caller is captured
+func (writer *MultiWriter) CallerLevel() LevelType {
+	return writer.callerLevel
+}
+
+// SetCallerLevel set lowest level which caller is captured by every writer
+func (writer *MultiWriter) SetCallerLevel(level LevelType) {
+	writer.callerLevel = level
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetCallerLevel(level)
+	}
+}
+
+// reportCaller determines whether any writer of level needs caller
+func (writer *MultiWriter) reportCaller(level LevelType) bool {
+	for _, filter := range writer.routed(level) {
+		if filter.reportCaller(level) {
+			return true
+		}
+	}
+	return false
+}
+
 // SetHook set hook for every logging actions
 func (writer *MultiWriter) SetHook(hook Hook) {
+	writer.hook = adaptHook(hook)
+}
+
+// SetEntryHook set hook receiving the entry of every logging actions
+func (writer *MultiWriter) SetEntryHook(hook EntryHook) {
 	writer.hook = hook
 }
 
@@ -126,10 +261,86 @@ func (writer *MultiWriter) SetHookLevel(level LevelType) {
 	writer.hookLevel = level
 }
 
+// SetHookQueue set queue size and number of workers delivering async hooks
+func (writer *MultiWriter) SetHookQueue(size int, workers int) {
+	writer.hookPool.setQueue(size, workers)
+}
+
+// SetHookQueuePolicy set what to do when queue of async hooks is full
+func (writer *MultiWriter) SetHookQueuePolicy(policy HookQueuePolicy) {
+	writer.hookPool.setPolicy(policy)
+}
+
+// HookDropped return number of async hook events dropped
+func (writer *MultiWriter) HookDropped() uint64 {
+	return writer.hookPool.Dropped()
+}
+
+// SetErrorHandler set handler called with every error met by every writer
+func (writer *MultiWriter) SetErrorHandler(handler ErrorHandler) {
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetErrorHandler(handler)
+	}
+}
+
+// SetFallback set what to do with a message when a writer can not write it
+func (writer *MultiWriter) SetFallback(policy FallbackPolicy) {
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetFallback(policy)
+	}
+}
+
+// FlushLevel get level at or above which messages are flushed at once
+func (writer *MultiWriter) FlushLevel() LevelType {
+	return writer.flushLevel
+}
+
+// SetFlushLevel set level at or above which messages are flushed at once
+func (writer *MultiWriter) SetFlushLevel(level LevelType) {
+	writer.flushLevel = level
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetFlushLevel(level)
+	}
+}
+
+// FlushInterval get interval of flushing in background
+func (writer *MultiWriter) FlushInterval() time.Duration {
+	return writer.flushInterval
+}
+
+// SetFlushInterval set interval of flushing in background
+func (writer *MultiWriter) SetFlushInterval(interval time.Duration) {
+	writer.flushInterval = interval
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetFlushInterval(interval)
+	}
+}
+
+// SyncPolicy get sync policy
+func (writer *MultiWriter) SyncPolicy() SyncPolicy {
+	return writer.syncPolicy
+}
+
+// SetSyncPolicy set when files are synced to disk
+func (writer *MultiWriter) SetSyncPolicy(policy SyncPolicy, interval time.Duration) {
+	writer.syncPolicy = policy
+	for _, fileWriter := range writer.filters {
+		fileWriter.SetSyncPolicy(policy, interval)
+	}
+}
+
+// Errors return number of errors met by every writer
+func (writer *MultiWriter) Errors() (errors uint64) {
+	for _, fileWriter := range writer.filters {
+		errors += fileWriter.Errors()
+	}
+	return
+}
+
 // SetLevel set logging level threshold
 func (writer *MultiWriter) SetLevel(level LevelType) {
 	writer.level = level
-	for _, fileWriter := range writer.writers {
+	for _, fileWriter := range writer.filters {
 		fileWriter.SetLevel(level)
 	}
 }
@@ -139,174 +350,213 @@ func (writer *MultiWriter) Level() LevelType {
 	return writer.level
 }
 
-// Close close file writer
+// Close close every writer, it waits for async hook events queued to be
+// delivered
 func (writer *MultiWriter) Close() {
-	for _, fileWriter := range writer.writers {
+	for _, fileWriter := range writer.filters {
 		fileWriter.Close()
 	}
+	writer.hookPool.close()
 	writer.closed = true
 }
 
-func (writer *MultiWriter) write(level LevelType, args ...interface{}) {
-	defer func() {
-		// 异步调用log hook
-		if nil != writer.hook && !(level < writer.hookLevel) {
-			if writer.hookAsync {
-				go func(level LevelType, args ...interface{}) {
-					writer.hook.Fire(level, args...)
-				}(level, args...)
+// Reopen reopen every writer, it returns the first error met
+func (writer *MultiWriter) Reopen() (err error) {
+	for _, fileWriter := range writer.filters {
+		if reopenErr := fileWriter.Reopen(); nil == err {
+			err = reopenErr
+		}
+	}
+	return
+}
 
-			} else {
-				writer.hook.Fire(level, args...)
-			}
+// Rotate rotate files of every writer at once, it returns the first error
+// met
+func (writer *MultiWriter) Rotate() (err error) {
+	for _, fileWriter := range writer.filters {
+		if rotateErr := fileWriter.Rotate(); nil == err {
+			err = rotateErr
 		}
-	}()
+	}
+	return
+}
 
-	writer.writers[level].write(level, args...)
+// routed return writers receiving messages of level. FATAL and PANIC
+// messages go to writers of the highest level below them when no writer is
+// added for them, so that they are not dropped by configs written before.
+func (writer *MultiWriter) routed(level LevelType) []Writer {
+	if writers := writer.writers[level]; 0 != len(writers) || level < FATAL {
+		return writers
+	}
+	for lower := level - 1; lower >= TRACE; lower-- {
+		if writers := writer.writers[lower]; 0 != len(writers) {
+			return writers
+		}
+	}
+	return nil
 }
 
-func (writer *MultiWriter) writef(level LevelType, format string, args ...interface{}) {
-	defer func() {
-		// 异步调用log hook
-		if nil != writer.hook && !(level < writer.hookLevel) {
-			if writer.hookAsync {
-				go func(level LevelType, format string, args ...interface{}) {
-					writer.hook.Fire(level, fmt.Sprintf(format, args...))
-				}(level, format, args...)
+// dropped decides whether messages of level are dropped. FATAL and PANIC
+// messages are written into stderr when no writer takes them.
+func (writer *MultiWriter) dropped(level LevelType) bool {
+	return level < writer.level || (level < FATAL && 0 == len(writer.writers[level]))
+}
 
-			} else {
-				writer.hook.Fire(level, fmt.Sprintf(format, args...))
+func (writer *MultiWriter) write(level LevelType, fields Fields, args ...interface{}) {
+	if writer.dropped(level) {
+		return
+	}
 
-			}
+	entry := newEntry(level, fields, false, "", args)
+	entry.Logger = writer.name
+	if writer.reportCaller(level) {
+		entry.Caller = captureCaller(callerSkip)
+	}
+	writer.writeEntry(entry)
+}
+
+func (writer *MultiWriter) writef(level LevelType, fields Fields, format string, args ...interface{}) {
+	if writer.dropped(level) {
+		return
+	}
+
+	entry := newEntry(level, fields, true, format, args)
+	entry.Logger = writer.name
+	if writer.reportCaller(level) {
+		entry.Caller = captureCaller(callerSkip)
+	}
+	writer.writeEntry(entry)
+}
+
+// writeEntry dispatches entry to every writer of its level, the hook receives
+// bytes encoded by the first writer of the level
+func (writer *MultiWriter) writeEntry(entry *Entry) *Entry {
+	hooked := nil != writer.hook && !(entry.Level < writer.hookLevel)
+	if hooked && hookEncoded(writer.hook) {
+		entry = entry.copy()
+		entry.encoded = true
+	}
+
+	filters := writer.routed(entry.Level)
+	if 0 == len(filters) && entry.Level >= FATAL {
+		writeStderr(entry)
+	}
+
+	var written *Entry
+	for _, filter := range filters {
+		if filtered := filter.writeEntry(entry); nil == written {
+			written = filtered
 		}
-	}()
+	}
+
+	// 异步调用log hook
+	if hooked {
+		if nil != written && entry.encoded {
+			entry.Encoded = written.Encoded
+		}
+		fireHook(writer.hook, writer.hookPool, hookAsync(writer.hookAsync, entry.Level), entry)
+	}
+	return entry
+}
+
+// WithFields derive a writer attaching fields to every message
+func (writer *MultiWriter) WithFields(fields Fields) Writer {
+	return newFieldsWriter(writer, fields)
+}
 
-	writer.writers[level].writef(level, format, args...)
+// With derive a writer attaching alternating keys and values as fields to
+// every message
+func (writer *MultiWriter) With(pairs ...interface{}) Writer {
+	return writer.WithFields(fieldsFromPairs(pairs...))
 }
 
 // flush flush logs to disk
 func (writer *MultiWriter) flush() {
-	for _, writer := range writer.writers {
-		writer.flush()
+	for _, filter := range writer.filters {
+		filter.flush()
 	}
 }
 
 // Trace trace
 func (writer *MultiWriter) Trace(args ...interface{}) {
-	_, ok := writer.writers[TRACE]
-	if !ok || TRACE < writer.level {
-		return
-	}
-
-	writer.write(TRACE, args...)
+	writer.write(TRACE, nil, args...)
 }
 
 // Tracef tracef
 func (writer *MultiWriter) Tracef(format string, args ...interface{}) {
-	_, ok := writer.writers[TRACE]
-	if !ok || TRACE < writer.level {
-		return
-	}
-
-	writer.writef(TRACE, format, args...)
+	writer.writef(TRACE, nil, format, args...)
 }
 
 // Debug debug
 func (writer *MultiWriter) Debug(args ...interface{}) {
-	_, ok := writer.writers[DEBUG]
-	if !ok || DEBUG < writer.level {
-		return
-	}
-
-	writer.write(DEBUG, args...)
+	writer.write(DEBUG, nil, args...)
 }
 
 // Debugf debugf
 func (writer *MultiWriter) Debugf(format string, args ...interface{}) {
-	_, ok := writer.writers[DEBUG]
-	if !ok || DEBUG < writer.level {
-		return
-	}
-
-	writer.writef(DEBUG, format, args...)
+	writer.writef(DEBUG, nil, format, args...)
 }
 
 // Info info
 func (writer *MultiWriter) Info(args ...interface{}) {
-	_, ok := writer.writers[INFO]
-	if !ok || INFO < writer.level {
-		return
-	}
-
-	writer.write(INFO, args...)
+	writer.write(INFO, nil, args...)
 }
 
 // Infof infof
 func (writer *MultiWriter) Infof(format string, args ...interface{}) {
-	_, ok := writer.writers[INFO]
-	if !ok || INFO < writer.level {
-		return
-	}
-
-	writer.writef(INFO, format, args...)
+	writer.writef(INFO, nil, format, args...)
 }
 
 // Warn warn
 func (writer *MultiWriter) Warn(args ...interface{}) {
-	_, ok := writer.writers[WARNING]
-	if !ok || WARNING < writer.level {
-		return
-	}
-
-	writer.write(WARNING, args...)
+	writer.write(WARNING, nil, args...)
 }
 
 // Warnf warnf
 func (writer *MultiWriter) Warnf(format string, args ...interface{}) {
-	_, ok := writer.writers[WARNING]
-	if !ok || WARNING < writer.level {
-		return
-	}
-
-	writer.writef(WARNING, format, args...)
+	writer.writef(WARNING, nil, format, args...)
 }
 
 // Error error
 func (writer *MultiWriter) Error(args ...interface{}) {
-	_, ok := writer.writers[ERROR]
-	if !ok || ERROR < writer.level {
-		return
-	}
-
-	writer.write(ERROR, args...)
+	writer.write(ERROR, nil, args...)
 }
 
 // Errorf error
 func (writer *MultiWriter) Errorf(format string, args ...interface{}) {
-	_, ok := writer.writers[ERROR]
-	if !ok || ERROR < writer.level {
-		return
-	}
-
-	writer.writef(ERROR, format, args...)
+	writer.writef(ERROR, nil, format, args...)
 }
 
 // Critical critical
 func (writer *MultiWriter) Critical(args ...interface{}) {
-	_, ok := writer.writers[CRITICAL]
-	if !ok || CRITICAL < writer.level {
-		return
-	}
-
-	writer.write(CRITICAL, args...)
+	writer.write(CRITICAL, nil, args...)
 }
 
 // Criticalf criticalf
 func (writer *MultiWriter) Criticalf(format string, args ...interface{}) {
-	_, ok := writer.writers[CRITICAL]
-	if !ok || CRITICAL < writer.level {
-		return
-	}
+	writer.writef(CRITICAL, nil, format, args...)
+}
+
+// Fatal fatal
+func (writer *MultiWriter) Fatal(args ...interface{}) {
+	writer.write(FATAL, nil, args...)
+	fatal()
+}
+
+// Fatalf fatalf
+func (writer *MultiWriter) Fatalf(format string, args ...interface{}) {
+	writer.writef(FATAL, nil, format, args...)
+	fatal()
+}
+
+// Panic panic
+func (writer *MultiWriter) Panic(args ...interface{}) {
+	writer.write(PANIC, nil, args...)
+	panicking(fmt.Sprint(args...))
+}
 
-	writer.writef(CRITICAL, format, args...)
+// Panicf panicf
+func (writer *MultiWriter) Panicf(format string, args ...interface{}) {
+	writer.writef(PANIC, nil, format, args...)
+	panicking(fmt.Sprintf(format, args...))
 }
@@ -0,0 +1,211 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultNameTemplate names files rotated by size or lines like
+	// app.log.20161016-153000.001
+	DefaultNameTemplate = "%f.%d{20060102-150405}.%i"
+
+	// time layout of %d without argument in name templates
+	defaultNameTimeFormat = "20060102-150405"
+)
+
+var (
+	// ErrInvalidNameTemplate invalid name template of rotated files
+	ErrInvalidNameTemplate = errors.New("Invalid name template, %i is required.")
+)
+
+// namePart is a literal text or a conversion of a name template
+type namePart struct {
+	// conversion verb, 0 for literal text
+	verb byte
+	// literal text, or argument of the conversion
+	text string
+}
+
+// nameTemplate names files rotated by size or lines, files are never
+// renamed again once rotated.
+//
+// Conversions supported:
+//
+//	%f        name of the file rotated, with suffix of time base logrotate
+//	%d        time of logrotate in defaultNameTimeFormat
+//	%d{fmt}   time of logrotate in Go time layout fmt
+//	%i        sequence, from 001, following the largest one used so far
+//	%H        hostname
+//	%P        process id
+//	%%        percent sign
+type nameTemplate struct {
+	template string
+	parts    []namePart
+}
+
+// newNameTemplate compiles template of rotated file names
+func newNameTemplate(template string) (*nameTemplate, error) {
+	parts, err := parseTemplate(template, "dfiHP")
+	if nil != err {
+		return nil, ErrInvalidNameTemplate
+	}
+
+	// names would collide without sequence
+	for _, part := range parts {
+		if 'i' == part.verb {
+			return &nameTemplate{template: template, parts: parts}, nil
+		}
+	}
+	return nil, ErrInvalidNameTemplate
+}
+
+// parseTemplate compiles template into literal texts and conversions of
+// verbs, every conversion may have an {argument}. %d without argument is
+// in defaultNameTimeFormat, %% is a percent sign.
+func parseTemplate(template string, verbs string) ([]namePart, error) {
+	var parts []namePart
+
+	// literal text not compiled into parts yet
+	var literal []byte
+	for i := 0; i < len(template); i++ {
+		if PLACEHOLDER != template[i] {
+			literal = append(literal, template[i])
+			continue
+		}
+
+		i++
+		if i >= len(template) {
+			return nil, ErrInvalidNameTemplate
+		}
+		verb := template[i]
+
+		// optional {argument} of the conversion
+		var arg string
+		if i+1 < len(template) && '{' == template[i+1] {
+			end := strings.IndexByte(template[i+2:], '}')
+			if end < 0 {
+				return nil, ErrInvalidNameTemplate
+			}
+			arg = template[i+2 : i+2+end]
+			i += 2 + end
+		}
+
+		if PLACEHOLDER == verb {
+			literal = append(literal, PLACEHOLDER)
+			continue
+		}
+		if strings.IndexByte(verbs, verb) < 0 {
+			return nil, ErrInvalidNameTemplate
+		}
+		if 'd' == verb && "" == arg {
+			arg = defaultNameTimeFormat
+		}
+
+		if 0 != len(literal) {
+			parts = append(parts, namePart{text: string(literal)})
+			literal = nil
+		}
+		parts = append(parts, namePart{verb: verb, text: arg})
+	}
+	if 0 != len(literal) {
+		parts = append(parts, namePart{text: string(literal)})
+	}
+	return parts, nil
+}
+
+// render return the name of file rotated at now with sequence
+func (nameTemplate *nameTemplate) render(fileName string, now time.Time, sequence int) string {
+	return renderParts(nameTemplate.parts, fileName, now, sequence)
+}
+
+// renderParts return the text of parts of a name template
+func renderParts(parts []namePart, fileName string, now time.Time, sequence int) string {
+	buffer := new(bytes.Buffer)
+	for _, part := range parts {
+		switch part.verb {
+		case 0:
+			buffer.WriteString(part.text)
+		case 'f':
+			buffer.WriteString(fileName)
+		case 'd':
+			buffer.WriteString(now.Format(part.text))
+		case 'i':
+			fmt.Fprintf(buffer, "%03d", sequence)
+		case 'H':
+			buffer.WriteString(hostname)
+		case 'P':
+			buffer.WriteString(pid)
+		}
+	}
+	return buffer.String()
+}
+
+// next return the name of file rotated at now, its sequence follows the
+// largest one used, compressed or not, so that names of files removed by
+// retention are never used again
+func (nameTemplate *nameTemplate) next(fileName string, now time.Time) string {
+	sequence := 1
+	for k, part := range nameTemplate.parts {
+		if 'i' != part.verb {
+			continue
+		}
+
+		// names of every sequence share the text around it
+		prefix := renderParts(nameTemplate.parts[:k], fileName, now, 0)
+		suffix := renderParts(nameTemplate.parts[k+1:], fileName, now, 0)
+		matches, _ := filepath.Glob(globQuote(prefix) + "*")
+		for _, match := range matches {
+			used := strings.TrimSuffix(strings.TrimSuffix(match, CompressSuffix), suffix)
+			if n, err := strconv.Atoi(strings.TrimPrefix(used, prefix)); nil == err && n >= sequence {
+				sequence = n + 1
+			}
+		}
+		break
+	}
+
+	for ; ; sequence++ {
+		name := nameTemplate.render(fileName, now, sequence)
+		if !archiveExists(name) {
+			return name
+		}
+	}
+}
+
+// glob return pattern matching every file named by the template, baseName
+// is the file name configured. When time rotated, %f matches names with
+// every suffix of time base logrotate.
+func (nameTemplate *nameTemplate) glob(baseName string, timeRotated bool) string {
+	buffer := new(bytes.Buffer)
+	for _, part := range nameTemplate.parts {
+		switch part.verb {
+		case 0:
+			buffer.WriteString(globQuote(part.text))
+		case 'f':
+			buffer.WriteString(globQuote(baseName))
+			if timeRotated {
+				buffer.WriteString(".*")
+			}
+		default:
+			buffer.WriteString("*")
+		}
+	}
+	return buffer.String()
+}
+
+// archiveExists checks whether a rotated file exists, compressed or not
+func archiveExists(path string) bool {
+	if _, err := os.Lstat(path); nil == err {
+		return true
+	}
+	_, err := os.Lstat(path + CompressSuffix)
+	return nil == err
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestNameTemplate(t *testing.T) {
+	now := time.Date(2016, 10, 16, 15, 30, 0, 0, time.Local)
+
+	nameTemplate, err := newNameTemplate(DefaultNameTemplate)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if name := nameTemplate.render("app.log", now, 1); "app.log.20161016-153000.001" != name {
+		t.Errorf("render default template wrong. name: %s", name)
+	}
+	if glob := nameTemplate.glob("app.log", false); "app.log.*.*" != glob {
+		t.Errorf("glob of default template wrong. glob: %s", glob)
+	}
+	if glob := nameTemplate.glob("app.log", true); "app.log.*.*.*" != glob {
+		t.Errorf("glob of time rotated default template wrong. glob: %s", glob)
+	}
+
+	nameTemplate, err = newNameTemplate("%f-%H-%P-%d-%%-%i")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if name := nameTemplate.render("app.log", now, 12); "app.log-"+hostname+"-"+pid+"-20161016-153000-%-012" != name {
+		t.Errorf("render template wrong. name: %s", name)
+	}
+
+	for _, template := range []string{"%f.%d", "%f.%i%", "%f.%d{2006.%i", "%f.%x.%i"} {
+		if _, err := newNameTemplate(template); ErrInvalidNameTemplate != err {
+			t.Errorf("template %s should be invalid", template)
+		}
+	}
+}
+
+func TestNameTemplateNextSequence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2016, 10, 16, 15, 30, 0, 0, time.Local)
+	nameTemplate, err := newNameTemplate("%f.%d{20060102}.%i")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	name := path.Join(dir, "app.log")
+	for sequence := 1; sequence <= 3; sequence++ {
+		ioutil.WriteFile(nameTemplate.render(name, now, sequence), nil, 0644)
+	}
+	os.Rename(nameTemplate.render(name, now, 3), nameTemplate.render(name, now, 3)+CompressSuffix)
+
+	// old archives removed by retention, the next sequence still follows
+	// the largest one, compressed or not
+	os.Remove(nameTemplate.render(name, now, 1))
+	os.Remove(nameTemplate.render(name, now, 2))
+	if next := nameTemplate.next(name, now); nameTemplate.render(name, now, 4) != next {
+		t.Errorf("sequence of removed archive reused. next: %s", next)
+	}
+
+	os.Remove(nameTemplate.render(name, now, 3) + CompressSuffix)
+	if next := nameTemplate.next(name, now); nameTemplate.render(name, now, 1) != next {
+		t.Errorf("sequence should start from 1 without archives. next: %s", next)
+	}
+}
+
+func TestFileSinkNameTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "template.log")
+	sink, err := NewFileSink(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := sink.SetNameTemplate("%f.%d{20060102}.%i"); nil != err {
+		t.Fatal(err)
+	}
+	sink.SetRotateLines(1)
+	sink.SetRetentions(3)
+	writer := newSinkWriter(sink)
+
+	writer.Info("first")
+	writer.Info("second")
+	first := name + "." + timeCache.Now().Format("20060102") + ".001"
+	if _, err := os.Stat(first); nil != err {
+		t.Fatalf("rotated file not named by template. err: %s", err.Error())
+	}
+
+	for i := 0; i < 5; i++ {
+		writer.Info("more")
+	}
+	writer.Close()
+
+	// files rotated are never renamed, newest retentions are kept
+	matches, _ := filepath.Glob(name + ".*")
+	sort.Strings(matches)
+	if 3 != len(matches) || name+"."+timeCache.Now().Format("20060102")+".004" != matches[0] {
+		t.Errorf("rotated files wrong. files: %v", matches)
+	}
+}
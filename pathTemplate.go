@@ -0,0 +1,110 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidPathTemplate invalid path template of time rotated files
+	ErrInvalidPathTemplate = errors.New("Invalid path template, %d is required.")
+)
+
+// pathTemplate names files rotated by time with directories partitioned by
+// date, such as logs/%d{2006/01/02}/%f for logs/2016/10/16/app.log.
+// Relative templates are resolved against the directory of the file
+// configured, not the working directory.
+//
+// Conversions supported:
+//
+//	%f        base name of the file configured
+//	%d{fmt}   start of the period in Go time layout fmt, path separators
+//	          in fmt make directories
+//	%H        hostname
+//	%%        percent sign
+type pathTemplate struct {
+	template string
+	parts    []namePart
+}
+
+// newPathTemplate compiles template of time rotated file paths
+func newPathTemplate(template string) (*pathTemplate, error) {
+	parts, err := parseTemplate(template, "dfH")
+	if nil != err {
+		return nil, ErrInvalidPathTemplate
+	}
+
+	// paths would be the same in every period without date
+	for _, part := range parts {
+		if 'd' == part.verb {
+			return &pathTemplate{template: template, parts: parts}, nil
+		}
+	}
+	return nil, ErrInvalidPathTemplate
+}
+
+// render return the path of the file written in the period starting at
+// start, fileName is the file name configured
+func (pathTemplate *pathTemplate) render(fileName string, start time.Time) string {
+	return resolvePath(fileName, renderParts(pathTemplate.parts, filepath.Base(fileName), start, 0))
+}
+
+// glob return pattern matching every file named by the template in any
+// period, compressed or not
+func (pathTemplate *pathTemplate) glob(fileName string) string {
+	buffer := new(bytes.Buffer)
+	for _, part := range pathTemplate.parts {
+		switch part.verb {
+		case 0:
+			buffer.WriteString(globQuote(part.text))
+		case 'f':
+			buffer.WriteString(globQuote(filepath.Base(fileName)))
+		case 'H':
+			buffer.WriteString(globQuote(hostname))
+		case 'd':
+			// every element of the date matches within its directory
+			elements := strings.Split(time.Time{}.Format(part.text), string(filepath.Separator))
+			for i := range elements {
+				elements[i] = "*"
+			}
+			buffer.WriteString(strings.Join(elements, string(filepath.Separator)))
+		}
+	}
+	return resolvePath(fileName, buffer.String()+"*")
+}
+
+// root return the directory holding every date directory, it is the
+// directory of the literal text before the first conversion
+func (pathTemplate *pathTemplate) root(fileName string) string {
+	if 0 == pathTemplate.parts[0].verb {
+		return resolvePath(fileName, filepath.Dir(pathTemplate.parts[0].text))
+	}
+	return filepath.Dir(fileName)
+}
+
+// resolvePath resolves path relative to the directory of fileName
+func resolvePath(fileName string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(fileName), path)
+}
+
+// removeEmptyDirs removes dir and its parents under root while they are
+// empty, root itself is kept
+func removeEmptyDirs(dir string, root string) {
+	dir = filepath.Clean(dir)
+	root = filepath.Clean(root)
+	for dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)) {
+		if err := os.Remove(dir); nil != err {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPathTemplate(t *testing.T) {
+	pathTemplate, err := newPathTemplate("/var/log/app/%d{2006/01/02}/%f")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2016, 10, 16, 15, 30, 0, 0, time.Local)
+	if name := pathTemplate.render("/var/log/app.log", now); "/var/log/app/2016/10/16/app.log" != name {
+		t.Errorf("path rendered wrong. path: %s", name)
+	}
+	if pattern := pathTemplate.glob("/var/log/app.log"); "/var/log/app/*/*/*/app.log*" != pattern {
+		t.Errorf("glob wrong. pattern: %s", pattern)
+	}
+	if root := pathTemplate.root("/var/log/app.log"); "/var/log/app" != root {
+		t.Errorf("root wrong. root: %s", root)
+	}
+
+	// relative template is resolved against directory of the file
+	pathTemplate, err = newPathTemplate("logs/%d{2006/01/02}/%f")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if name := pathTemplate.render("/var/log/app.log", now); "/var/log/logs/2016/10/16/app.log" != name {
+		t.Errorf("relative path rendered wrong. path: %s", name)
+	}
+	if pattern := pathTemplate.glob("/var/log/app.log"); "/var/log/logs/*/*/*/app.log*" != pattern {
+		t.Errorf("relative glob wrong. pattern: %s", pattern)
+	}
+	if root := pathTemplate.root("/var/log/app.log"); "/var/log/logs" != root {
+		t.Errorf("relative root wrong. root: %s", root)
+	}
+
+	for _, template := range []string{"/var/log/app.log", "/var/log/%i/app.log", "/var/log/%d{2006"} {
+		if _, err := newPathTemplate(template); ErrInvalidPathTemplate != err {
+			t.Errorf("template should be invalid. template: %s", template)
+		}
+	}
+}
+
+func TestRemoveEmptyDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// directories are removed up to the first one not empty
+	os.MkdirAll(path.Join(dir, "2016", "10", "16"), 0755)
+	os.MkdirAll(path.Join(dir, "2016", "11"), 0755)
+	removeEmptyDirs(path.Join(dir, "2016", "10", "16"), dir)
+
+	if _, err := os.Stat(path.Join(dir, "2016", "10")); !os.IsNotExist(err) {
+		t.Error("empty date directories not removed")
+	}
+	if _, err := os.Stat(path.Join(dir, "2016", "11")); nil != err {
+		t.Errorf("date directory removed wrong. err: %s", err.Error())
+	}
+}
+
+func TestFileSinkPathTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "app.log")
+	sink, err := NewFileSink(name, true)
+	if nil != err {
+		t.Fatal(err)
+	}
+	initial := sink.currentFileName
+	sink.SetRotatePeriod(time.Second)
+	sink.SetRetentions(1)
+	if err := sink.SetPathTemplate(path.Join(dir, "logs", "%d{2006-01-02/15/04-05}", "%f")); nil != err {
+		t.Fatal(err)
+	}
+	first := sink.currentFileName
+	writer := newSinkWriter(sink)
+
+	// the file opened first is removed as nothing has been written
+	if _, err := os.Stat(initial); !os.IsNotExist(err) {
+		t.Errorf("empty file opened first not removed. file: %s", initial)
+	}
+
+	for deadline := time.Now().Add(3500 * time.Millisecond); time.Now().Before(deadline); {
+		writer.Info("partitioned")
+		time.Sleep(50 * time.Millisecond)
+	}
+	current := sink.currentFileName
+	writer.Close()
+
+	if matched, _ := filepath.Match(path.Join(dir, "logs", "*", "*", "*", "app.log"), current); !matched {
+		t.Errorf("file not written in date directories. file: %s", current)
+	}
+	if _, err := os.Stat(current); nil != err {
+		t.Errorf("current file not found. err: %s", err.Error())
+	}
+
+	// expired files are removed with their empty directories
+	if _, err := os.Stat(filepath.Dir(first)); !os.IsNotExist(err) {
+		t.Errorf("expired date directory not removed. dir: %s", filepath.Dir(first))
+	}
+
+	// closed sink never opens files
+	if err := sink.SetPathTemplate("logs/%d{2006-01-02}/%f"); ErrSinkClosed != err {
+		t.Errorf("path template of closed sink should not be set. err: %v", err)
+	}
+}
+
+func TestFileSinkRelativePathTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// absolute path with relative template, the working directory differs
+	name := path.Join(dir, "app.log")
+	sink, err := NewFileSink(name, true)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.SetPathTemplate("logs/%d{2006/01/02}/%f"); nil != err {
+		t.Fatal(err)
+	}
+	sink.SetSymlink(true)
+
+	if matched, _ := filepath.Match(path.Join(dir, "logs", "*", "*", "*", "app.log"), sink.currentFileName); !matched {
+		t.Errorf("file not written under directory of path. file: %s", sink.currentFileName)
+	}
+	if target, _ := os.Readlink(name); !strings.HasPrefix(target, "logs/") {
+		t.Errorf("symlink target should be relative to its directory. target: %s", target)
+	}
+	if _, err := os.Stat(name); nil != err {
+		t.Errorf("symlink broken. err: %s", err.Error())
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// periods of time base logrotate
+
+	// PeriodMinute rotates log file every minute
+	PeriodMinute = time.Minute
+	// PeriodHour rotates log file every hour
+	PeriodHour = time.Hour
+	// PeriodDay rotates log file every day, it is the default period
+	PeriodDay = 24 * time.Hour
+	// PeriodWeek rotates log file every week, starting from monday
+	PeriodWeek = 7 * PeriodDay
+
+	// suffix layouts of time base rotated files, the finest unit of period
+	// decides which one is used
+
+	// HourFormat suffix layout of hourly rotated files
+	HourFormat = "2006-01-02-15"
+	// MinuteFormat suffix layout of minutely rotated files
+	MinuteFormat = "2006-01-02-15-04"
+	// SecondFormat suffix layout of rotated files with periods in seconds
+	SecondFormat = "2006-01-02-15-04-05"
+)
+
+var (
+	// ErrInvalidRotatePeriod invalid period of time base logrotate
+	ErrInvalidRotatePeriod = errors.New("Invalid log rotate period.")
+)
+
+// ParsePeriod parse period of time base logrotate, it is one of minute,
+// hour, day, week or a positive duration accepted by time.ParseDuration,
+// such as "30m" or "6h"
+func ParsePeriod(period string) (time.Duration, error) {
+	switch strings.ToLower(period) {
+	case "minute":
+		return PeriodMinute, nil
+	case "hour":
+		return PeriodHour, nil
+	case "day":
+		return PeriodDay, nil
+	case "week":
+		return PeriodWeek, nil
+	}
+
+	duration, err := time.ParseDuration(period)
+	if nil != err || duration < time.Second {
+		return 0, ErrInvalidRotatePeriod
+	}
+	return duration, nil
+}
+
+// periodStart return start of the period t lies in. Periods in whole days
+// start at local midnight, weeks start on monday, shorter periods are
+// counted from local midnight.
+func periodStart(t time.Time, period time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	if PeriodWeek == period {
+		// time.Weekday starts from sunday
+		return midnight.AddDate(0, 0, -(int(midnight.Weekday())+6)%7)
+	}
+
+	if 0 == period%PeriodDay {
+		// count days from unix epoch, in UTC to be free of daylight saving
+		days := int64(period / PeriodDay)
+		passed := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400 % days
+		return midnight.AddDate(0, 0, -int(passed))
+	}
+
+	return midnight.Add(t.Sub(midnight) / period * period)
+}
+
+// periodsAgo return start of the period n periods before the one starting
+// at start, periods in whole days are counted in calendar days
+func periodsAgo(start time.Time, period time.Duration, n int64) time.Time {
+	if 0 == period%PeriodDay {
+		return start.AddDate(0, 0, -int(n*int64(period/PeriodDay)))
+	}
+	return start.Add(-time.Duration(n) * period)
+}
+
+// periodLayout return suffix layout of files rotated by period
+func periodLayout(period time.Duration) string {
+	switch {
+	case 0 == period%PeriodDay:
+		return DateFormat
+	case 0 == period%time.Hour:
+		return HourFormat
+	case 0 == period%time.Minute:
+		return MinuteFormat
+	}
+	return SecondFormat
+}
+
+// periodFileName return name of the file t is written into when file is
+// rotated by period
+func periodFileName(fileName string, t time.Time, period time.Duration) string {
+	return fmt.Sprintf("%s.%s", fileName, periodStart(t, period).Format(periodLayout(period)))
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	cases := map[string]time.Duration{
+		"minute": PeriodMinute,
+		"Hour":   PeriodHour,
+		"day":    PeriodDay,
+		"week":   PeriodWeek,
+		"30m":    30 * time.Minute,
+		"6h":     6 * time.Hour,
+	}
+	for period, expected := range cases {
+		if duration, err := ParsePeriod(period); nil != err || expected != duration {
+			t.Errorf("parse period %s wrong. duration: %s, err: %v", period, duration, err)
+		}
+	}
+
+	for _, period := range []string{"", "monthly", "-1h", "10ms"} {
+		if _, err := ParsePeriod(period); ErrInvalidRotatePeriod != err {
+			t.Errorf("period %s should be invalid", period)
+		}
+	}
+}
+
+func TestPeriodFileName(t *testing.T) {
+	// 2016-03-09 is a wednesday
+	now := time.Date(2016, 3, 9, 17, 42, 35, 0, time.Local)
+	cases := []struct {
+		period   time.Duration
+		expected string
+	}{
+		{PeriodMinute, "a.log.2016-03-09-17-42"},
+		{PeriodHour, "a.log.2016-03-09-17"},
+		{6 * time.Hour, "a.log.2016-03-09-12"},
+		{15 * time.Minute, "a.log.2016-03-09-17-30"},
+		{10 * time.Second, "a.log.2016-03-09-17-42-30"},
+		{PeriodDay, "a.log.2016-03-09"},
+		{PeriodWeek, "a.log.2016-03-07"},
+	}
+	for _, c := range cases {
+		if fileName := periodFileName("a.log", now, c.period); c.expected != fileName {
+			t.Errorf("file name of period %s wrong. expected: %s, got: %s", c.period, c.expected, fileName)
+		}
+	}
+
+	// monday itself starts a week
+	monday := time.Date(2016, 3, 7, 0, 0, 0, 0, time.Local)
+	if start := periodStart(monday, PeriodWeek); !monday.Equal(start) {
+		t.Errorf("week start wrong. start: %s", start)
+	}
+
+	// retentions are counted in periods
+	start := periodStart(now, PeriodHour)
+	if fileName := periodFileName("a.log", periodsAgo(start, PeriodHour, 3), PeriodHour); "a.log.2016-03-09-14" != fileName {
+		t.Errorf("expired file name wrong. got: %s", fileName)
+	}
+	start = periodStart(now, PeriodWeek)
+	if fileName := periodFileName("a.log", periodsAgo(start, PeriodWeek, 1), PeriodWeek); "a.log.2016-02-29" != fileName {
+		t.Errorf("expired file name wrong. got: %s", fileName)
+	}
+}
+
+func TestConfigPeriod(t *testing.T) {
+	config := new(Config)
+	config.Filters = []filter{{Levels: "info", RotateFile: rotateFile{Path: "/tmp/a.log", Type: TypeTimeBaseRotate, Period: "monthly"}}}
+	if ErrInvalidRotatePeriod != config.valid() {
+		t.Error("invalid period should fail validation")
+	}
+
+	config.Filters[0].RotateFile.Period = "hour"
+	if nil != config.valid() {
+		t.Error("valid period should pass validation")
+	}
+}
+
+func TestFileSinkSetRotatePeriod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "hourly.log")
+	sink, err := NewFileSink(name, true)
+	if nil != err {
+		t.Fatal(err)
+	}
+	dayFileName := sink.currentFileName
+
+	// the file of the day is never written once rotated hourly
+	sink.SetRotatePeriod(PeriodHour)
+	writer := newSinkWriter(sink)
+	writer.Info("hourly")
+	hourFileName := sink.currentFileName
+	writer.Close()
+
+	if periodFileName(name, timeCache.Now(), PeriodHour) != hourFileName {
+		t.Errorf("file not switched to the hour. file: %s", hourFileName)
+	}
+	if _, err := os.Stat(dayFileName); !os.IsNotExist(err) {
+		t.Errorf("empty file of the day should be removed. err: %v", err)
+	}
+	if content := readFile(t, hourFileName); !strings.HasSuffix(content, "[INFO] hourly\n") {
+		t.Errorf("not written into file of the hour. content: %q", content)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":    0,
+		"7d":  7 * PeriodDay,
+		"36h": 36 * time.Hour,
+	}
+	for age, expected := range cases {
+		if duration, err := parseAge(age); nil != err || expected != duration {
+			t.Errorf("parse age %s wrong. duration: %s, err: %v", age, duration, err)
+		}
+	}
+
+	for _, age := range []string{"d", "-1d", "week", "-1h"} {
+		if _, err := parseAge(age); ErrConfigBadAttributes != err {
+			t.Errorf("age %s should be invalid", age)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+const (
+	// StackField is the key of the field holding the stack of a panic
+	// recovered by Recover or RecoverAndLog
+	StackField = "stack"
+)
+
+// Recover recovers a panic of the goroutine and logs it with CRITICAL by
+// the default writer, it must be deferred directly:
+//
+//	go func() {
+//		defer blog4go.Recover()
+//		...
+//	}()
+func Recover() {
+	if value := recover(); nil != value {
+		recovered(CRITICAL, value)
+	}
+}
+
+// RecoverAndLog recovers a panic of the goroutine and logs it with level by
+// the default writer, it must be deferred directly. With PANIC the panic
+// goes on with the value recovered after logged, with FATAL the process
+// exits with code 1.
+func RecoverAndLog(level LevelType) {
+	if value := recover(); nil != value {
+		recovered(level, value)
+	}
+}
+
+// recovered logs value and stack of a panic as one entry, the stack is
+// passed as field StackField so that hooks and JSONEncoder get it apart.
+// Logs are flushed before returning.
+func recovered(level LevelType, value interface{}) {
+	stack := string(bytes.TrimSpace(debug.Stack()))
+
+	if writer := Default(); nil == writer {
+		fmt.Fprintf(os.Stderr, "panic: %v\n%s\n", value, stack)
+	} else {
+		writer.writef(level, Fields{StackField: stack}, "panic: %v", value)
+	}
+	settle()
+
+	switch level {
+	case FATAL:
+		exit(1)
+	case PANIC:
+		panic(value)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "recover.log")
+	writer, err := NewBaseFileLogger(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	hook := new(MyEntryHook)
+	writer.SetEntryHook(hook)
+	writer.SetHookAsync(false)
+	writer.SetReportCaller(true)
+	replaced := SetDefault(writer)
+	defer SetDefault(replaced)
+
+	var line int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer Recover()
+		line = nextLine()
+		panic("boom")
+	}()
+	<-done
+
+	// logs are flushed before Recover returns
+	if 1 != len(hook.entries) || "panic: boom" != hook.entries[0].Message() {
+		t.Fatalf("entry hook not called with panic. entries: %d", len(hook.entries))
+	}
+	content := readFile(t, name)
+	if !strings.Contains(content, fmt.Sprintf("[CRITICAL] %s panic: boom stack=", hook.entries[0].Caller)) || !strings.Contains(content, "TestRecover") {
+		t.Errorf("panic not logged with stack. content: %s", content)
+	}
+	if stack, ok := hook.entries[0].Fields[StackField].(string); !ok || !strings.HasPrefix(stack, "goroutine ") {
+		t.Errorf("stack field wrong. fields: %v", hook.entries[0].Fields)
+	}
+	// caller is the function panicked, not Recover
+	if caller := hook.entries[0].Caller; nil == caller || "recover_test.go" != path.Base(caller.File) || line != caller.Line {
+		t.Errorf("caller of panic wrong. expected line: %d, caller: %+v", line, caller)
+	}
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "recover.log")
+	writer, err := NewBaseFileLogger(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	writer.SetEncoder(JSONEncoder{})
+	replaced := SetDefault(writer)
+	defer SetDefault(replaced)
+
+	func() {
+		defer RecoverAndLog(ERROR)
+		panic("swallowed")
+	}()
+
+	// PANIC panics on with the value recovered
+	value := interface{}(nil)
+	func() {
+		defer func() { value = recover() }()
+		defer RecoverAndLog(PANIC)
+		panic(ErrInvalidLevel)
+	}()
+	if ErrInvalidLevel != value {
+		t.Errorf("panic value not passed on. value: %v", value)
+	}
+
+	content := readFile(t, name)
+	if !strings.Contains(content, `"level":"ERROR","msg":"panic: swallowed"`) || !strings.Contains(content, `"stack":"goroutine `) {
+		t.Errorf("panic not logged as structured entry. content: %s", content)
+	}
+	if !strings.Contains(content, `"level":"PANIC"`) {
+		t.Errorf("panic passed on not logged. content: %s", content)
+	}
+}
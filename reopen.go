@@ -0,0 +1,86 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var (
+	// file sinks not closed yet, reopened by ReopenFiles
+	fileSinks = make(map[*FileSink]struct{})
+	// lock of fileSinks
+	fileSinksLock = new(sync.Mutex)
+
+	// signals received by the SIGHUP handler, nil if not handled
+	sighup chan os.Signal
+	// lock of sighup
+	sighupLock = new(sync.Mutex)
+)
+
+// registerFileSink adds sink to file sinks reopened by ReopenFiles
+func registerFileSink(sink *FileSink) {
+	fileSinksLock.Lock()
+	defer fileSinksLock.Unlock()
+	fileSinks[sink] = struct{}{}
+}
+
+// unregisterFileSink removes sink from file sinks reopened by ReopenFiles
+func unregisterFileSink(sink *FileSink) {
+	fileSinksLock.Lock()
+	defer fileSinksLock.Unlock()
+	delete(fileSinks, sink)
+}
+
+// ReopenFiles reopen every file written by file sinks not closed, of every
+// writer. Errors are passed to error handler of each sink, the first one is
+// returned.
+func ReopenFiles() (err error) {
+	fileSinksLock.Lock()
+	sinks := make([]*FileSink, 0, len(fileSinks))
+	for sink := range fileSinks {
+		sinks = append(sinks, sink)
+	}
+	fileSinksLock.Unlock()
+
+	for _, sink := range sinks {
+		reopenErr := sink.Reopen()
+		if nil != reopenErr && ErrSinkClosed != reopenErr {
+			sink.handleError(reopenErr)
+			if nil == err {
+				err = reopenErr
+			}
+		}
+	}
+	return
+}
+
+// HandleSIGHUP set whether every file is reopened by ReopenFiles when
+// SIGHUP received, for logrotate of the system in create mode. It is not
+// handled by default.
+func HandleSIGHUP(handled bool) {
+	sighupLock.Lock()
+	defer sighupLock.Unlock()
+
+	if handled == (nil != sighup) {
+		return
+	}
+
+	if !handled {
+		signal.Stop(sighup)
+		close(sighup)
+		sighup = nil
+		return
+	}
+
+	sighup = make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func(signals chan os.Signal) {
+		for range signals {
+			ReopenFiles()
+		}
+	}(sighup)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitFile waits for name to be created
+func waitFile(name string) bool {
+	for i := 0; i < 300; i++ {
+		if _, err := os.Stat(name); nil == err {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestWriterReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writer, err := NewFileLogger(dir, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	name := path.Join(dir, "info.log")
+	writer.Info("before")
+	writer.flush()
+	os.Rename(name, name+".moved")
+
+	if err := writer.Reopen(); nil != err {
+		t.Fatal(err)
+	}
+	writer.Info("after")
+	writer.flush()
+
+	if content := readFile(t, name); !strings.HasSuffix(content, " after\n") || strings.Contains(content, "before") {
+		t.Errorf("file not reopened. content: %q", content)
+	}
+}
+
+func TestFileSinkReopenMoved(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "moved.log")
+	writer, err := NewBaseFileLogger(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	// deleted by others, reopened by daemon
+	os.Remove(name)
+	if !waitFile(name) {
+		t.Fatal("file deleted not reopened")
+	}
+	writer.Info("reopened")
+	writer.flush()
+	if content := readFile(t, name); !strings.HasSuffix(content, " reopened\n") {
+		t.Errorf("not written into file reopened. content: %q", content)
+	}
+}
+
+func TestFileSinkReopenClosed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink, err := NewFileSink(path.Join(dir, "closed.log"), false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	sink.Close()
+
+	if err := sink.Reopen(); ErrSinkClosed != err {
+		t.Errorf("closed sink should not be reopened. err: %v", err)
+	}
+	if err := sink.resetFile(); ErrSinkClosed != err {
+		t.Errorf("closed sink should not be reset. err: %v", err)
+	}
+}
+
+func TestFileSinkReopenPeriod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "period.log")
+	sink, err := NewFileSink(name, true)
+	if nil != err {
+		t.Fatal(err)
+	}
+	var rotations []rotation
+	var l sync.Mutex
+	sink.SetRotateHandler(func(oldPath string, newPath string, reason RotateReason) error {
+		l.Lock()
+		defer l.Unlock()
+		rotations = append(rotations, rotation{oldPath, newPath, reason})
+		return nil
+	})
+
+	// still writing the file of the last period when reopened
+	yesterday := sink.periodFileName(time.Now().AddDate(0, 0, -1))
+	sink.lock.Lock()
+	err = sink.open(yesterday)
+	sink.lock.Unlock()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := sink.Reopen(); nil != err {
+		t.Fatal(err)
+	}
+	if err := sink.timeRotate(time.Now()); nil != err {
+		t.Fatal(err)
+	}
+	sink.Close()
+
+	// file of the last period is archived once, by time base logrotate
+	if 1 != len(rotations) || yesterday != rotations[0].oldPath || RotateByTime != rotations[0].reason {
+		t.Errorf("file of the last period not archived on reopen. rotations: %v", rotations)
+	}
+}
+
+func TestHandleSIGHUP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "sighup.log")
+	writer, err := NewBaseFileLogger(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	HandleSIGHUP(true)
+	defer HandleSIGHUP(false)
+
+	os.Rename(name, name+".1")
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	if !waitFile(name) {
+		t.Fatal("file not reopened on SIGHUP")
+	}
+
+	// closed sinks are not reopened any more
+	writer.Close()
+	fileSinksLock.Lock()
+	_, ok := fileSinks[writer.(*sinkWriter).sink.(*FileSink)]
+	fileSinksLock.Unlock()
+	if ok {
+		t.Error("closed file sink should be unregistered")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRotateCommandTimeout is how long the command of
+	// CommandRotateHandler may run before it is killed
+	DefaultRotateCommandTimeout = time.Minute
+)
+
+var (
+	// ErrRotateCommandTimeout onRotate command killed as it ran too long
+	ErrRotateCommandTimeout = errors.New("onRotate command timed out.")
+)
+
+// RotateReason tells why a file was rotated
+type RotateReason int
+
+const (
+	// RotateByTime file rotated by time base logrotate
+	RotateByTime RotateReason = iota
+	// RotateBySize file rotated as its size reached the threshold
+	RotateBySize
+	// RotateByLines file rotated as its lines reached the threshold
+	RotateByLines
+	// RotateByRequest file rotated by Rotate, such as at startup
+	RotateByRequest
+)
+
+// String return name of the reason
+func (reason RotateReason) String() string {
+	switch reason {
+	case RotateByTime:
+		return "time"
+	case RotateBySize:
+		return "size"
+	case RotateByLines:
+		return "lines"
+	case RotateByRequest:
+		return "request"
+	}
+	return "unknown"
+}
+
+// RotateHandler is called after a file is rotated, with oldPath the file
+// was written as, newPath the file archived as, after renamed and compressed.
+// It is called in background one by one, files rotated are swept after it
+// returns. Error returned is passed to the error handler.
+type RotateHandler func(oldPath string, newPath string, reason RotateReason) error
+
+// CommandRotateHandler create a RotateHandler running command with path of
+// the file archived appended as the last argument. Arguments of command are
+// split by spaces, no shell is involved. Old path and reason are passed with
+// environment variables BLOG4GO_OLD_PATH and BLOG4GO_ROTATE_REASON.
+// The command is killed after DefaultRotateCommandTimeout.
+func CommandRotateHandler(command string) RotateHandler {
+	return CommandRotateHandlerWithTimeout(command, DefaultRotateCommandTimeout)
+}
+
+// CommandRotateHandlerWithTimeout create a RotateHandler like
+// CommandRotateHandler, the command is killed after timeout so that
+// background work of rotated files, and closing the sink, never hang on it.
+// An error wrapping ErrRotateCommandTimeout is returned then.
+func CommandRotateHandlerWithTimeout(command string, timeout time.Duration) RotateHandler {
+	args := strings.Fields(command)
+	return func(oldPath string, newPath string, reason RotateReason) error {
+		if 0 == len(args) {
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, args[0], append(args[1:], newPath)...)
+		cmd.Env = append(os.Environ(),
+			"BLOG4GO_OLD_PATH="+oldPath,
+			"BLOG4GO_ROTATE_REASON="+reason.String())
+		// children left by the command may keep output open
+		cmd.WaitDelay = time.Second
+		output, err := cmd.CombinedOutput()
+		if context.DeadlineExceeded == ctx.Err() {
+			return fmt.Errorf("onRotate command %q killed after %s: %w", command, timeout, ErrRotateCommandTimeout)
+		}
+		if nil != err {
+			return fmt.Errorf("onRotate command %q failed: %s: %s", command, err.Error(), strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+}
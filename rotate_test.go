@@ -0,0 +1,170 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rotation is a call of RotateHandler
+type rotation struct {
+	oldPath string
+	newPath string
+	reason  RotateReason
+}
+
+func TestRotateHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "handled.log")
+	sink, err := NewFileSink(name, false)
+	if nil != err {
+		t.Fatal(err)
+	}
+	var rotations []rotation
+	var l sync.Mutex
+	sink.SetRotateHandler(func(oldPath string, newPath string, reason RotateReason) error {
+		l.Lock()
+		defer l.Unlock()
+		rotations = append(rotations, rotation{oldPath, newPath, reason})
+		return nil
+	})
+	sink.SetCompress(true)
+	sink.SetRotateLines(1)
+	writer := newSinkWriter(sink)
+
+	writer.Info("first")
+	writer.Info("second")
+	writer.Close()
+
+	if 1 != len(rotations) {
+		t.Fatalf("rotate handler not called once. rotations: %v", rotations)
+	}
+	if expected := (rotation{name, name + ".1" + CompressSuffix, RotateByLines}); expected != rotations[0] {
+		t.Errorf("rotate handler arguments wrong. expected: %v, got: %v", expected, rotations[0])
+	}
+	if _, err := os.Stat(rotations[0].newPath); nil != err {
+		t.Errorf("file archived not found. err: %s", err.Error())
+	}
+}
+
+func TestCommandRotateHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archived := path.Join(dir, "archived.log.1")
+	ioutil.WriteFile(archived, []byte("archived\n"), 0644)
+
+	// path archived is the last argument
+	if err := CommandRotateHandler("chmod 600")("archived.log", archived, RotateBySize); nil != err {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(archived); 0600 != info.Mode().Perm() {
+		t.Errorf("command not run with path archived. mode: %s", info.Mode())
+	}
+
+	err = CommandRotateHandler("false")("archived.log", archived, RotateBySize)
+	if nil == err || !strings.Contains(err.Error(), "false") {
+		t.Errorf("command failed should be reported. err: %v", err)
+	}
+
+	// command hung is killed, even if its children keep output open
+	script := path.Join(dir, "hang.sh")
+	ioutil.WriteFile(script, []byte("sleep 30\n"), 0644)
+	start := time.Now()
+	err = CommandRotateHandlerWithTimeout("sh "+script, 100*time.Millisecond)("archived.log", archived, RotateBySize)
+	if !errors.Is(err, ErrRotateCommandTimeout) || time.Since(start) > 5*time.Second {
+		t.Errorf("command hung should be killed. err: %v, elapsed: %s", err, time.Since(start))
+	}
+}
+
+func TestFileSinkRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, timeRotated := range []bool{false, true} {
+		name := path.Join(dir, "forced.log")
+		sink, err := NewFileSink(name, timeRotated)
+		if nil != err {
+			t.Fatal(err)
+		}
+		current := sink.currentFileName
+		writer := newSinkWriter(sink)
+
+		writer.Info("before")
+		if err := writer.Rotate(); nil != err {
+			t.Fatal(err)
+		}
+		// empty file is never rotated
+		if err := writer.Rotate(); nil != err {
+			t.Fatal(err)
+		}
+		writer.Info("after")
+		writer.Close()
+
+		if content := readFile(t, current+".1"); !strings.Contains(content, "before") {
+			t.Errorf("file not rotated. timeRotated: %t, content: %s", timeRotated, content)
+		}
+		if _, err := os.Stat(current + ".2"); !os.IsNotExist(err) {
+			t.Errorf("empty file rotated. timeRotated: %t", timeRotated)
+		}
+		if content := readFile(t, current); !strings.Contains(content, "after") || strings.Contains(content, "before") {
+			t.Errorf("fresh file wrong. timeRotated: %t, content: %s", timeRotated, content)
+		}
+	}
+
+	if name := RotateByRequest.String(); "request" != name {
+		t.Errorf("reason name wrong. name: %s", name)
+	}
+}
+
+func TestRotateOnStartup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "deploy.log")
+	ioutil.WriteFile(name, []byte("last deployment\n"), 0644)
+	configFile := path.Join(dir, "startup.xml")
+	config := `<blog4go minlevel="info">
+	<filter levels="info">
+		<rotatefile path="` + name + `" type="size" rotateSize="1000000" rotateOnStartup="true"></rotatefile>
+	</filter>
+</blog4go>`
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); nil != err {
+		t.Fatal(err)
+	}
+
+	writer, err := NewLoggerFromConfigAsFile(configFile)
+	if nil != err {
+		t.Fatal(err)
+	}
+	writer.Info("this deployment")
+	writer.Close()
+
+	if content := readFile(t, name+".1"); "last deployment\n" != content {
+		t.Errorf("file of last deployment not rotated. content: %s", content)
+	}
+	if content := readFile(t, name); strings.Contains(content, "last deployment") {
+		t.Errorf("file of this deployment not fresh. content: %s", content)
+	}
+}
@@ -0,0 +1,594 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSinkClosed show that an entry is written to a closed sink
+	ErrSinkClosed = errors.New("Sink has been closed.")
+	// ErrSinkTypeNotFound sink type is not registered
+	ErrSinkTypeNotFound = errors.New("Sink type not registered.")
+	// ErrSinkTypeRegistered sink type is registered already
+	ErrSinkTypeRegistered = errors.New("Sink type has been already registered.")
+
+	// registered sink factories by sink type
+	sinkFactories = make(map[string]SinkFactory)
+	// lock of sinkFactories
+	sinkFactoriesLock = new(sync.RWMutex)
+)
+
+// Sink interface is the output backend of a writer, it can be implemented
+// outside this package and turned into a Writer with NewSinkLogger.
+// The writer filters messages by level, captures caller, calls hooks and
+// builds an Entry for every logging action, the sink only outputs it.
+// WriteEntry output an entry, it may be called concurrently. A sink
+// encoding entries should keep the bytes written in Encoded of the entry
+// when KeepEncoded of the entry is true.
+// Flush write any buffered data to the underlying output.
+// Close flush and free any resources of the sink.
+type Sink interface {
+	WriteEntry(entry *Entry) error
+	Flush() error
+	Close() error
+}
+
+// EncodedSink is an optional interface a Sink may implement when its log
+// lines are formatted by an Encoder, SetEncoder of the writer is passed to it.
+type EncodedSink interface {
+	SetEncoder(encoder Encoder)
+}
+
+// ColoredSink is an optional interface a Sink may implement when it
+// supports logging with colors.
+type ColoredSink interface {
+	SetColored(colored bool)
+	Colored() bool
+}
+
+// RotatedSink is an optional interface a Sink may implement when it
+// supports logrotate, logrotate functions of the writer are passed to it.
+type RotatedSink interface {
+	SetTimeRotated(timeRotated bool)
+	TimeRotated() bool
+	SetRotateSize(rotateSize int64)
+	RotateSize() int64
+	SetRotateLines(rotateLines int)
+	RotateLines() int
+	SetRetentions(retentions int64)
+	Retentions() int64
+}
+
+// RotateSink is an optional interface a Sink may implement when its target
+// can be rotated on demand, it is used by Rotate of the writer.
+type RotateSink interface {
+	Rotate() error
+}
+
+// PeriodRotatedSink is an optional interface a RotatedSink may implement
+// when time base logrotate supports periods other than a day.
+type PeriodRotatedSink interface {
+	SetRotatePeriod(period time.Duration)
+	RotatePeriod() time.Duration
+}
+
+// RetentionSink is an optional interface a Sink may implement when it
+// removes rotated files by age or total size.
+type RetentionSink interface {
+	SetMaxAge(maxAge time.Duration)
+	MaxAge() time.Duration
+	SetMaxBytes(maxBytes int64)
+	MaxBytes() int64
+}
+
+// SinkFactory creates a sink from attributes of a sink element in config
+// file, such as <sink type="kafka" brokers="127.0.0.1:9092"></sink>.
+// attrs holds every attribute except type.
+type SinkFactory func(attrs map[string]string) (Sink, error)
+
+// RegisterSink registers factory as the creator of sinks with given type,
+// so that config file can refer to it by the type attribute of a sink
+// element. It is usually called in init function of the package providing
+// the sink.
+func RegisterSink(sinkType string, factory SinkFactory) error {
+	sinkFactoriesLock.Lock()
+	defer sinkFactoriesLock.Unlock()
+
+	if _, ok := sinkFactories[sinkType]; ok {
+		return ErrSinkTypeRegistered
+	}
+	sinkFactories[sinkType] = factory
+	return nil
+}
+
+// unregisterSink removes factory of sinks with given type
+func unregisterSink(sinkType string) {
+	sinkFactoriesLock.Lock()
+	defer sinkFactoriesLock.Unlock()
+	delete(sinkFactories, sinkType)
+}
+
+// newSink creates a sink with the factory registered as sinkType
+func newSink(sinkType string, attrs map[string]string) (Sink, error) {
+	sinkFactoriesLock.RLock()
+	factory, ok := sinkFactories[sinkType]
+	sinkFactoriesLock.RUnlock()
+
+	if !ok {
+		return nil, ErrSinkTypeNotFound
+	}
+	return factory(attrs)
+}
+
+// sinkWriter is a Writer writing every message into a sink.
+// It does logging level filtering, caller capturing and calls user defined
+// hook for every logging action, everything about output is done by sink.
+type sinkWriter struct {
+	level LevelType
+
+	closed bool
+
+	// output backend
+	sink Sink
+
+	// name of the writer, written by %c of PatternEncoder
+	name string
+
+	// caller of logging actions
+	callerReported bool
+	callerLevel    LevelType
+
+	// log hook
+	hook      EntryHook
+	hookLevel LevelType
+	hookAsync bool
+	// delivers async hook events
+	hookPool *hookPool
+
+	// errors met by the writer and sink
+	errorHandler   ErrorHandler
+	fallbackPolicy FallbackPolicy
+	// number of errors met, accessed atomically
+	errorCount uint64
+
+	// messages at or above flushLevel are flushed at once
+	flushLevel LevelType
+}
+
+// NewSinkLogger create an independent writer writing every message into sink
+func NewSinkLogger(sink Sink) Writer {
+	return newSinkWriter(sink)
+}
+
+// newSinkWriter create a sinkWriter with default settings
+func newSinkWriter(sink Sink) *sinkWriter {
+	writer := new(sinkWriter)
+	writer.level = TRACE
+	writer.closed = false
+	writer.sink = sink
+
+	writer.callerReported = false
+	writer.callerLevel = TRACE
+
+	// log hook
+	writer.hook = nil
+	writer.hookLevel = DEBUG
+	writer.hookAsync = true
+	writer.hookPool = newHookPool()
+
+	writer.fallbackPolicy = FallbackDrop
+	writer.flushLevel = LevelType(-1)
+	if sink, ok := sink.(ErrorHandledSink); ok {
+		sink.SetErrorHandler(writer.handleError)
+	}
+	registerSinkWriter(writer)
+
+	return writer
+}
+
+func (writer *sinkWriter) write(level LevelType, fields Fields, args ...interface{}) {
+	if writer.closed || level < writer.level {
+		return
+	}
+
+	entry := newEntry(level, fields, false, "", args)
+	entry.Logger = writer.name
+	if writer.reportCaller(level) {
+		entry.Caller = captureCaller(callerSkip)
+	}
+	writer.writeEntry(entry)
+}
+
+func (writer *sinkWriter) writef(level LevelType, fields Fields, format string, args ...interface{}) {
+	if writer.closed || level < writer.level {
+		return
+	}
+
+	entry := newEntry(level, fields, true, format, args)
+	entry.Logger = writer.name
+	if writer.reportCaller(level) {
+		entry.Caller = captureCaller(callerSkip)
+	}
+	writer.writeEntry(entry)
+}
+
+// writeEntry writes an entry into sink and calls hook, it returns the entry
+// written which is a copy when it is modified for this writer
+func (writer *sinkWriter) writeEntry(entry *Entry) *Entry {
+	if writer.closed {
+		return entry
+	}
+
+	hooked := nil != writer.hook && !(entry.Level < writer.hookLevel)
+	stripCaller := nil != entry.Caller && !writer.reportCaller(entry.Level)
+	encoded := entry.encoded || (hooked && hookEncoded(writer.hook))
+	// entry may be shared with other writers, modify a copy of it
+	if stripCaller || encoded {
+		entry = entry.copy()
+		entry.encoded = encoded
+		if stripCaller {
+			entry.Caller = nil
+		}
+	}
+
+	if err := writer.sink.WriteEntry(entry); nil != err {
+		writer.fallback(entry, err)
+	} else if writer.flushLevel.valid() && entry.Level >= writer.flushLevel {
+		if err := writer.sink.Flush(); nil != err {
+			writer.handleError(err)
+		}
+	}
+
+	// 异步调用log hook
+	if hooked {
+		fireHook(writer.hook, writer.hookPool, hookAsync(writer.hookAsync, entry.Level), entry)
+	}
+	return entry
+}
+
+// Level get level
+func (writer *sinkWriter) Level() LevelType {
+	return writer.level
+}
+
+// SetLevel set logger level
+func (writer *sinkWriter) SetLevel(level LevelType) {
+	writer.level = level
+}
+
+// SetEncoder set encoder formatting log lines if sink is an EncodedSink
+func (writer *sinkWriter) SetEncoder(encoder Encoder) {
+	if sink, ok := writer.sink.(EncodedSink); ok {
+		sink.SetEncoder(encoder)
+	}
+}
+
+// Name get name of the writer
+func (writer *sinkWriter) Name() string {
+	return writer.name
+}
+
+// SetName set name of the writer
+func (writer *sinkWriter) SetName(name string) {
+	writer.name = name
+}
+
+// ReportCaller get whether caller of logging actions is captured
+func (writer *sinkWriter) ReportCaller() bool {
+	return writer.callerReported
+}
+
+// SetReportCaller set whether caller of logging actions is captured
+func (writer *sinkWriter) SetReportCaller(reportCaller bool) {
+	writer.callerReported = reportCaller
+}
+
+// CallerLevel get lowest level which caller is captured
+func (writer *sinkWriter) CallerLevel() LevelType {
+	return writer.callerLevel
+}
+
+// SetCallerLevel set lowest level which caller is captured
+func (writer *sinkWriter) SetCallerLevel(level LevelType) {
+	writer.callerLevel = level
+}
+
+// reportCaller determines whether caller is captured for level
+func (writer *sinkWriter) reportCaller(level LevelType) bool {
+	return writer.callerReported && !(level < writer.callerLevel)
+}
+
+// SetHook set hook for logging action
+func (writer *sinkWriter) SetHook(hook Hook) {
+	writer.hook = adaptHook(hook)
+}
+
+// SetEntryHook set hook receiving the entry of logging action
+func (writer *sinkWriter) SetEntryHook(hook EntryHook) {
+	writer.hook = hook
+}
+
+// SetHookAsync set hook async for sink writer
+func (writer *sinkWriter) SetHookAsync(async bool) {
+	writer.hookAsync = async
+}
+
+// SetHookLevel set when hook will be called
+func (writer *sinkWriter) SetHookLevel(level LevelType) {
+	writer.hookLevel = level
+}
+
+// SetHookQueue set queue size and number of workers delivering async hooks
+func (writer *sinkWriter) SetHookQueue(size int, workers int) {
+	writer.hookPool.setQueue(size, workers)
+}
+
+// SetHookQueuePolicy set what to do when queue of async hooks is full
+func (writer *sinkWriter) SetHookQueuePolicy(policy HookQueuePolicy) {
+	writer.hookPool.setPolicy(policy)
+}
+
+// HookDropped return number of async hook events dropped
+func (writer *sinkWriter) HookDropped() uint64 {
+	return writer.hookPool.Dropped()
+}
+
+// TimeRotated get timeRotated if sink is a RotatedSink
+func (writer *sinkWriter) TimeRotated() bool {
+	if sink, ok := writer.sink.(RotatedSink); ok {
+		return sink.TimeRotated()
+	}
+	return false
+}
+
+// SetTimeRotated toggle time base logrotate if sink is a RotatedSink
+func (writer *sinkWriter) SetTimeRotated(timeRotated bool) {
+	if sink, ok := writer.sink.(RotatedSink); ok {
+		sink.SetTimeRotated(timeRotated)
+	}
+}
+
+// Retentions get retentions if sink is a RotatedSink
+func (writer *sinkWriter) Retentions() int64 {
+	if sink, ok := writer.sink.(RotatedSink); ok {
+		return sink.Retentions()
+	}
+	return 0
+}
+
+// SetRetentions set how many logs will keep if sink is a RotatedSink
+func (writer *sinkWriter) SetRetentions(retentions int64) {
+	if sink, ok := writer.sink.(RotatedSink); ok {
+		sink.SetRetentions(retentions)
+	}
+}
+
+// RotateSize get rotateSize if sink is a RotatedSink
+func (writer *sinkWriter) RotateSize() int64 {
+	if sink, ok := writer.sink.(RotatedSink); ok {
+		return sink.RotateSize()
+	}
+	return 0
+}
+
+// SetRotateSize set size when logroatate if sink is a RotatedSink
+func (writer *sinkWriter) SetRotateSize(rotateSize int64) {
+	if sink, ok := writer.sink.(RotatedSink); ok {
+		sink.SetRotateSize(rotateSize)
+	}
+}
+
+// RotatePeriod get period of time base logrotate if sink is a
+// PeriodRotatedSink
+func (writer *sinkWriter) RotatePeriod() time.Duration {
+	if sink, ok := writer.sink.(PeriodRotatedSink); ok {
+		return sink.RotatePeriod()
+	}
+	return 0
+}
+
+// SetRotatePeriod set period of time base logrotate if sink is a
+// PeriodRotatedSink
+func (writer *sinkWriter) SetRotatePeriod(period time.Duration) {
+	if sink, ok := writer.sink.(PeriodRotatedSink); ok {
+		sink.SetRotatePeriod(period)
+	}
+}
+
+// MaxAge get max age of rotated files if sink is a RetentionSink
+func (writer *sinkWriter) MaxAge() time.Duration {
+	if sink, ok := writer.sink.(RetentionSink); ok {
+		return sink.MaxAge()
+	}
+	return 0
+}
+
+// SetMaxAge set max age of rotated files if sink is a RetentionSink
+func (writer *sinkWriter) SetMaxAge(maxAge time.Duration) {
+	if sink, ok := writer.sink.(RetentionSink); ok {
+		sink.SetMaxAge(maxAge)
+	}
+}
+
+// MaxBytes get max total size of logs if sink is a RetentionSink
+func (writer *sinkWriter) MaxBytes() int64 {
+	if sink, ok := writer.sink.(RetentionSink); ok {
+		return sink.MaxBytes()
+	}
+	return 0
+}
+
+// SetMaxBytes set max total size of logs if sink is a RetentionSink
+func (writer *sinkWriter) SetMaxBytes(maxBytes int64) {
+	if sink, ok := writer.sink.(RetentionSink); ok {
+		sink.SetMaxBytes(maxBytes)
+	}
+}
+
+// RotateLines get rotateLines if sink is a RotatedSink
+func (writer *sinkWriter) RotateLines() int {
+	if sink, ok := writer.sink.(RotatedSink); ok {
+		return sink.RotateLines()
+	}
+	return 0
+}
+
+// SetRotateLines set line number when logrotate if sink is a RotatedSink
+func (writer *sinkWriter) SetRotateLines(rotateLines int) {
+	if sink, ok := writer.sink.(RotatedSink); ok {
+		sink.SetRotateLines(rotateLines)
+	}
+}
+
+// Colored get colored if sink is a ColoredSink
+func (writer *sinkWriter) Colored() bool {
+	if sink, ok := writer.sink.(ColoredSink); ok {
+		return sink.Colored()
+	}
+	return false
+}
+
+// SetColored set logging color if sink is a ColoredSink
+func (writer *sinkWriter) SetColored(colored bool) {
+	if sink, ok := writer.sink.(ColoredSink); ok {
+		sink.SetColored(colored)
+	}
+}
+
+// Close close the writer and its sink, it waits for async hook events
+// queued to be delivered
+func (writer *sinkWriter) Close() {
+	if writer.closed {
+		return
+	}
+
+	writer.closed = true
+	unregisterSinkWriter(writer)
+	if err := writer.sink.Close(); nil != err {
+		writer.handleError(err)
+	}
+	writer.hookPool.close()
+}
+
+// Reopen reopen target of the sink if sink is a ReopenSink
+func (writer *sinkWriter) Reopen() error {
+	if sink, ok := writer.sink.(ReopenSink); ok {
+		return sink.Reopen()
+	}
+	return nil
+}
+
+// Rotate rotate target of the sink at once if sink is a RotateSink
+func (writer *sinkWriter) Rotate() error {
+	if sink, ok := writer.sink.(RotateSink); ok {
+		return sink.Rotate()
+	}
+	return nil
+}
+
+// WithFields derive a writer attaching fields to every message
+func (writer *sinkWriter) WithFields(fields Fields) Writer {
+	return newFieldsWriter(writer, fields)
+}
+
+// With derive a writer attaching alternating keys and values as fields to
+// every message
+func (writer *sinkWriter) With(pairs ...interface{}) Writer {
+	return writer.WithFields(fieldsFromPairs(pairs...))
+}
+
+// flush flush buffered logs of sink
+func (writer *sinkWriter) flush() {
+	if err := writer.sink.Flush(); nil != err {
+		writer.handleError(err)
+	}
+}
+
+// Trace trace
+func (writer *sinkWriter) Trace(args ...interface{}) {
+	writer.write(TRACE, nil, args...)
+}
+
+// Tracef tracef
+func (writer *sinkWriter) Tracef(format string, args ...interface{}) {
+	writer.writef(TRACE, nil, format, args...)
+}
+
+// Debug debug
+func (writer *sinkWriter) Debug(args ...interface{}) {
+	writer.write(DEBUG, nil, args...)
+}
+
+// Debugf debugf
+func (writer *sinkWriter) Debugf(format string, args ...interface{}) {
+	writer.writef(DEBUG, nil, format, args...)
+}
+
+// Info info
+func (writer *sinkWriter) Info(args ...interface{}) {
+	writer.write(INFO, nil, args...)
+}
+
+// Infof infof
+func (writer *sinkWriter) Infof(format string, args ...interface{}) {
+	writer.writef(INFO, nil, format, args...)
+}
+
+// Warn warn
+func (writer *sinkWriter) Warn(args ...interface{}) {
+	writer.write(WARNING, nil, args...)
+}
+
+// Warnf warnf
+func (writer *sinkWriter) Warnf(format string, args ...interface{}) {
+	writer.writef(WARNING, nil, format, args...)
+}
+
+// Error error
+func (writer *sinkWriter) Error(args ...interface{}) {
+	writer.write(ERROR, nil, args...)
+}
+
+// Errorf error
+func (writer *sinkWriter) Errorf(format string, args ...interface{}) {
+	writer.writef(ERROR, nil, format, args...)
+}
+
+// Critical critical
+func (writer *sinkWriter) Critical(args ...interface{}) {
+	writer.write(CRITICAL, nil, args...)
+}
+
+// Criticalf criticalf
+func (writer *sinkWriter) Criticalf(format string, args ...interface{}) {
+	writer.writef(CRITICAL, nil, format, args...)
+}
+
+// Fatal fatal
+func (writer *sinkWriter) Fatal(args ...interface{}) {
+	writer.write(FATAL, nil, args...)
+	fatal()
+}
+
+// Fatalf fatalf
+func (writer *sinkWriter) Fatalf(format string, args ...interface{}) {
+	writer.writef(FATAL, nil, format, args...)
+	fatal()
+}
+
+// Panic panic
+func (writer *sinkWriter) Panic(args ...interface{}) {
+	writer.write(PANIC, nil, args...)
+	panicking(fmt.Sprint(args...))
+}
+
+// Panicf panicf
+func (writer *sinkWriter) Panicf(format string, args ...interface{}) {
+	writer.writef(PANIC, nil, format, args...)
+	panicking(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memorySink keeps every entry written in memory
+type memorySink struct {
+	attrs   map[string]string
+	entries []*Entry
+	flushed int
+	closed  bool
+	lock    sync.Mutex
+}
+
+func (sink *memorySink) WriteEntry(entry *Entry) error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if sink.closed {
+		return ErrSinkClosed
+	}
+	sink.entries = append(sink.entries, entry)
+	return nil
+}
+
+func (sink *memorySink) Flush() error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.flushed++
+	return nil
+}
+
+func (sink *memorySink) Close() error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.closed = true
+	return nil
+}
+
+func TestSinkLogger(t *testing.T) {
+	sink := new(memorySink)
+	writer := NewSinkLogger(sink)
+	writer.SetLevel(INFO)
+	writer.SetName("memory")
+	writer.SetReportCaller(true)
+
+	hook := NewMyHook()
+	writer.SetHook(hook)
+	writer.SetHookAsync(false)
+	writer.SetHookLevel(INFO)
+
+	writer.Debug("dropped")
+	line := nextLine()
+	writer.With("user", "eddie").Infof("hello %s", "sink")
+	writer.flush()
+
+	if 1 != len(sink.entries) {
+		t.Fatalf("sink entries wrong. entries: %d", len(sink.entries))
+	}
+	entry := sink.entries[0]
+	if INFO != entry.Level || "hello sink" != entry.Message() || "eddie" != entry.Fields["user"] || "memory" != entry.Logger {
+		t.Errorf("sink entry wrong. entry: %+v", entry)
+	}
+	if nil == entry.Caller || !strings.HasSuffix(entry.Caller.File, "sink_test.go") || line != entry.Caller.Line {
+		t.Errorf("sink entry caller wrong. caller: %v", entry.Caller)
+	}
+	if 1 != hook.Cnt() || "hello sink" != hook.Message() {
+		t.Errorf("hook not called with entry. cnt: %d, message: %s", hook.Cnt(), hook.Message())
+	}
+	if 1 != sink.flushed {
+		t.Errorf("sink not flushed. flushed: %d", sink.flushed)
+	}
+
+	// optional interfaces not implemented by sink
+	writer.SetEncoder(JSONEncoder{})
+	writer.SetRotateSize(1024)
+	writer.SetColored(true)
+	if 0 != writer.RotateSize() || writer.Colored() {
+		t.Error("sink without optional interfaces should ignore settings")
+	}
+
+	writer.Close()
+	writer.Info("closed")
+	if !sink.closed || 1 != len(sink.entries) {
+		t.Error("sink not closed with writer")
+	}
+}
+
+func TestRegisterSink(t *testing.T) {
+	var created *memorySink
+	factory := func(attrs map[string]string) (Sink, error) {
+		created = &memorySink{attrs: attrs}
+		return created, nil
+	}
+
+	if err := RegisterSink("memory", factory); nil != err {
+		t.Fatalf("register sink failed. err: %s", err.Error())
+	}
+	defer unregisterSink("memory")
+	if err := RegisterSink("memory", factory); ErrSinkTypeRegistered != err {
+		t.Errorf("duplicate register should fail. err: %v", err)
+	}
+	if _, err := newSink("unknown", nil); ErrSinkTypeNotFound != err {
+		t.Errorf("unknown sink type should fail. err: %v", err)
+	}
+
+	configFile := "/tmp/blog4go_sink.xml"
+	config := `<blog4go>
+	<filter levels="info,error">
+		<sink type="memory" topic="app" brokers="127.0.0.1:9092"></sink>
+	</filter>
+</blog4go>`
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); nil != err {
+		t.Fatal(err.Error())
+	}
+	defer os.Remove(configFile)
+
+	writer, err := NewLoggerFromConfigAsFile(configFile)
+	if nil != err {
+		t.Fatalf("create logger with registered sink failed. err: %s", err.Error())
+	}
+	defer writer.Close()
+
+	if nil == created || "app" != created.attrs["topic"] || "127.0.0.1:9092" != created.attrs["brokers"] {
+		t.Fatalf("sink attributes wrong. sink: %+v", created)
+	}
+	if _, ok := created.attrs["type"]; ok {
+		t.Error("type should not be passed to sink factory")
+	}
+
+	writer.Info("info")
+	writer.Warn("dropped")
+	writer.Error("error")
+	if 2 != len(created.entries) || "info" != created.entries[0].Message() || "error" != created.entries[1].Message() {
+		t.Errorf("registered sink entries wrong. entries: %d", len(created.entries))
+	}
+}
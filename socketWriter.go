@@ -4,29 +4,33 @@ package blog4go
 
 import (
 	"bytes"
-	"fmt"
 	"net"
 	"sync"
 )
 
-// SocketWriter is a socket logger
-type SocketWriter struct {
-	level LevelType
-
+// SocketSink is a socket sink, every entry is encoded and written to the
+// connection at once
+type SocketSink struct {
 	closed bool
 
-	// log hook
-	hook      Hook
-	hookLevel LevelType
-	hookAsync bool
-
 	// socket
 	writer net.Conn
 
+	// encoder formats every message into a log line
+	encoder Encoder
+
 	lock *sync.Mutex
 }
 
-// NewSocketWriter creates a socket writer, singlton
+// SocketWriter is the writer created by NewSocketWriter and
+// NewSocketLogger.
+//
+// Deprecated: use Writer, socket writers are a Writer with a SocketSink.
+type SocketWriter struct {
+	*sinkWriter
+}
+
+// NewSocketWriter initialize the default writer as a socket writer, singlton
 func NewSocketWriter(network string, address string) (err error) {
 	singltonLock.Lock()
 	defer singltonLock.Unlock()
@@ -43,282 +47,83 @@ func NewSocketWriter(network string, address string) (err error) {
 	return nil
 }
 
-// newSocketWriter creates a socket writer, not singlton
-func newSocketWriter(network string, address string) (socketWriter *SocketWriter, err error) {
-	socketWriter = new(SocketWriter)
-	socketWriter.level = DEBUG
-	socketWriter.closed = false
-	socketWriter.lock = new(sync.Mutex)
-
-	// log hook
-	socketWriter.hook = nil
-	socketWriter.hookLevel = DEBUG
-
-	conn, err := net.Dial(network, address)
+// NewSocketLogger creates an independent socket writer, not singlton
+func NewSocketLogger(network string, address string) (Writer, error) {
+	writer, err := newSocketWriter(network, address)
 	if nil != err {
 		return nil, err
 	}
-	socketWriter.writer = conn
-
-	blog = socketWriter
-	return socketWriter, nil
-}
-
-func (writer *SocketWriter) write(level LevelType, args ...interface{}) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-
-	if writer.closed {
-		return
-	}
-
-	defer func() {
-		// call log hook
-		if nil != writer.hook && !(level < writer.hookLevel) {
-			if writer.hookAsync {
-				go func(level LevelType, args ...interface{}) {
-					writer.hook.Fire(level, args...)
-				}(level, args...)
-
-			} else {
-				writer.hook.Fire(level, args...)
-
-			}
-		}
-	}()
-
-	buffer := bytes.NewBuffer(timeCache.Format())
-	buffer.WriteString(level.prefix())
-	buffer.WriteString(fmt.Sprint(args...))
-	writer.writer.Write(buffer.Bytes())
-}
-
-func (writer *SocketWriter) writef(level LevelType, format string, args ...interface{}) {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-
-	if writer.closed {
-		return
-	}
-
-	defer func() {
-
-		// call log hook
-		if nil != writer.hook && !(level < writer.hookLevel) {
-			if writer.hookAsync {
-				go func(level LevelType, format string, args ...interface{}) {
-					writer.hook.Fire(level, fmt.Sprintf(format, args...))
-				}(level, format, args...)
-
-			} else {
-				writer.hook.Fire(level, fmt.Sprintf(format, args...))
-			}
-		}
-	}()
-
-	buffer := bytes.NewBuffer(timeCache.Format())
-	buffer.WriteString(level.prefix())
-	buffer.WriteString(fmt.Sprintf(format, args...))
-	writer.writer.Write(buffer.Bytes())
-}
-
-// Level get level
-func (writer *SocketWriter) Level() LevelType {
-	return writer.level
-}
-
-// SetLevel set logger level
-func (writer *SocketWriter) SetLevel(level LevelType) {
-	writer.level = level
-}
-
-// SetHook set hook for logging action
-func (writer *SocketWriter) SetHook(hook Hook) {
-	writer.hook = hook
-}
-
-// SetHookAsync set hook async for base file writer
-func (writer *SocketWriter) SetHookAsync(async bool) {
-	writer.hookAsync = async
-}
-
-// SetHookLevel set when hook will be called
-func (writer *SocketWriter) SetHookLevel(level LevelType) {
-	writer.hookLevel = level
-}
-
-// TimeRotated do nothing
-func (writer *SocketWriter) TimeRotated() bool {
-	return false
-}
-
-// SetTimeRotated do nothing
-func (writer *SocketWriter) SetTimeRotated(timeRotated bool) {
-	return
-}
-
-// Retentions do nothing
-func (writer *SocketWriter) Retentions() int64 {
-	return 0
-}
-
-// SetRetentions do nothing
-func (writer *SocketWriter) SetRetentions(retentions int64) {
-	return
-}
-
-// RotateSize do nothing
-func (writer *SocketWriter) RotateSize() int64 {
-	return 0
+	return writer, nil
 }
 
-// SetRotateSize do nothing
-func (writer *SocketWriter) SetRotateSize(rotateSize int64) {
-	return
-}
-
-// RotateLines do nothing
-func (writer *SocketWriter) RotateLines() int {
-	return 0
-}
-
-// SetRotateLines do nothing
-func (writer *SocketWriter) SetRotateLines(rotateLines int) {
-	return
-}
-
-// Colored do nothing
-func (writer *SocketWriter) Colored() bool {
-	return false
-}
-
-// SetColored do nothing
-func (writer *SocketWriter) SetColored(colored bool) {
-	return
-}
-
-// Close will close the writer
-func (writer *SocketWriter) Close() {
-	writer.lock.Lock()
-	defer writer.lock.Unlock()
-	if writer.closed {
-		return
-	}
-
-	writer.writer.Close()
-	writer.writer = nil
-	writer.closed = true
-}
-
-// flush do nothing
-func (writer *SocketWriter) flush() {
-	return
-}
-
-// Trace trace
-func (writer *SocketWriter) Trace(args ...interface{}) {
-	if nil == writer.writer || TRACE < writer.level {
-		return
-	}
-
-	writer.write(TRACE, args...)
-}
-
-// Tracef tracef
-func (writer *SocketWriter) Tracef(format string, args ...interface{}) {
-	if nil == writer.writer || TRACE < writer.level {
-		return
-	}
-
-	writer.writef(TRACE, format, args...)
-}
-
-// Debug debug
-func (writer *SocketWriter) Debug(args ...interface{}) {
-	if nil == writer.writer || DEBUG < writer.level {
-		return
-	}
-
-	writer.write(DEBUG, args...)
-}
-
-// Debugf debugf
-func (writer *SocketWriter) Debugf(format string, args ...interface{}) {
-	if nil == writer.writer || DEBUG < writer.level {
-		return
+// newSocketWriter creates a socket writer, not singlton
+func newSocketWriter(network string, address string) (*SocketWriter, error) {
+	sink, err := NewSocketSink(network, address)
+	if nil != err {
+		return nil, err
 	}
 
-	writer.writef(DEBUG, format, args...)
+	writer := newSinkWriter(sink)
+	writer.SetLevel(DEBUG)
+	return &SocketWriter{sinkWriter: writer}, nil
 }
 
-// Info info
-func (writer *SocketWriter) Info(args ...interface{}) {
-	if nil == writer.writer || INFO < writer.level {
-		return
-	}
-
-	writer.write(INFO, args...)
-}
+// NewSocketSink creates a socket sink connected to address
+func NewSocketSink(network string, address string) (sink *SocketSink, err error) {
+	sink = new(SocketSink)
+	sink.closed = false
+	sink.lock = new(sync.Mutex)
+	sink.encoder = DefaultEncoder
 
-// Infof infof
-func (writer *SocketWriter) Infof(format string, args ...interface{}) {
-	if nil == writer.writer || INFO < writer.level {
-		return
+	conn, err := net.Dial(network, address)
+	if nil != err {
+		return nil, err
 	}
+	sink.writer = conn
 
-	writer.writef(INFO, format, args...)
+	return sink, nil
 }
 
-// Warn warn
-func (writer *SocketWriter) Warn(args ...interface{}) {
-	if nil == writer.writer || WARNING < writer.level {
-		return
-	}
-
-	writer.write(WARNING, args...)
-}
+// WriteEntry encodes an entry and writes it to the connection
+func (sink *SocketSink) WriteEntry(entry *Entry) error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
 
-// Warnf warnf
-func (writer *SocketWriter) Warnf(format string, args ...interface{}) {
-	if nil == writer.writer || WARNING < writer.level {
-		return
+	if sink.closed {
+		return ErrSinkClosed
 	}
 
-	writer.writef(WARNING, format, args...)
-}
-
-// Error error
-func (writer *SocketWriter) Error(args ...interface{}) {
-	if nil == writer.writer || ERROR < writer.level {
-		return
+	buffer := new(bytes.Buffer)
+	sink.encoder.Encode(buffer, entry)
+	if entry.KeepEncoded() {
+		entry.Encoded = buffer.Bytes()
 	}
-
-	writer.write(ERROR, args...)
+	_, err := sink.writer.Write(buffer.Bytes())
+	return err
 }
 
-// Errorf error
-func (writer *SocketWriter) Errorf(format string, args ...interface{}) {
-	if nil == writer.writer || ERROR < writer.level {
-		return
-	}
-
-	writer.writef(ERROR, format, args...)
+// SetEncoder set encoder formatting log lines
+func (sink *SocketSink) SetEncoder(encoder Encoder) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	sink.encoder = encoder
 }
 
-// Critical critical
-func (writer *SocketWriter) Critical(args ...interface{}) {
-	if nil == writer.writer || CRITICAL < writer.level {
-		return
+// Close will close the sink
+func (sink *SocketSink) Close() error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+	if sink.closed {
+		return nil
 	}
 
-	writer.write(CRITICAL, args...)
+	err := sink.writer.Close()
+	sink.writer = nil
+	sink.closed = true
+	return err
 }
 
-// Criticalf criticalf
-func (writer *SocketWriter) Criticalf(format string, args ...interface{}) {
-	if nil == writer.writer || CRITICAL < writer.level {
-		return
-	}
-
-	writer.writef(CRITICAL, format, args...)
+// Flush do nothing, every entry is written at once
+func (sink *SocketSink) Flush() error {
+	return nil
 }
@@ -122,8 +122,6 @@ func TestSignleSocketWriter(t *testing.T) {
 	go func() {
 		defer wg.Done()
 
-		initPrefix(false)
-
 		// begin listen udp packages on 127.0.0.1:12124
 		serverAddr, _ := net.ResolveUDPAddr("udp", "127.0.0.1:12124")
 		conn, err := net.ListenUDP("udp", serverAddr)
@@ -0,0 +1,51 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrSymlinkConflict a file which is not a symlink exists at the path of
+	// symlink
+	ErrSymlinkConflict = errors.New("A file which is not a symlink exists at the symlink path.")
+)
+
+// updateSymlink points symlink at link to target atomically. The new
+// symlink is created with a temporary name in the same directory and renamed
+// to link, so that link always exists while updated. Target in the same
+// directory or below is linked with relative path, so that the directory can
+// be moved, others are linked with absolute path.
+func updateSymlink(link string, target string) error {
+	if info, err := os.Lstat(link); nil == err && 0 == info.Mode()&os.ModeSymlink {
+		// never replace a real file
+		return ErrSymlinkConflict
+	}
+
+	dir := filepath.Dir(link)
+	// target relative to the working directory is never linked as it is
+	if absTarget, err := filepath.Abs(target); nil == err {
+		target = absTarget
+	}
+	if absDir, err := filepath.Abs(dir); nil == err {
+		if rel, err := filepath.Rel(absDir, target); nil == err && !strings.HasPrefix(rel, "..") {
+			target = rel
+		}
+	}
+
+	tmp := filepath.Join(dir, fmt.Sprintf(".%s.symlink", filepath.Base(link)))
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); nil != err {
+		return err
+	}
+	if err := os.Rename(tmp, link); nil != err {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
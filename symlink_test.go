@@ -0,0 +1,73 @@
+// Copyright (c) 2015, huangjunwei <huangjunwei@youmi.net>. All rights reserved.
+
+package blog4go
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	link := path.Join(dir, "app.log")
+	if err := updateSymlink(link, path.Join(dir, "app.log.2016-03-09")); nil != err {
+		t.Fatal(err)
+	}
+	if target, _ := os.Readlink(link); "app.log.2016-03-09" != target {
+		t.Errorf("symlink target in the same directory should be relative. target: %s", target)
+	}
+
+	if err := updateSymlink(link, "/var/log/app.log.2016-03-10"); nil != err {
+		t.Fatal(err)
+	}
+	if target, _ := os.Readlink(link); "/var/log/app.log.2016-03-10" != target {
+		t.Errorf("symlink not updated. target: %s", target)
+	}
+	if matches, _ := filepath.Glob(path.Join(dir, ".*")); 0 != len(matches) {
+		t.Errorf("temporary symlink left. files: %v", matches)
+	}
+
+	// a real file is never replaced
+	os.Remove(link)
+	ioutil.WriteFile(link, []byte("real\n"), 0644)
+	if err := updateSymlink(link, path.Join(dir, "app.log.2016-03-09")); ErrSymlinkConflict != err {
+		t.Errorf("real file should not be replaced. err: %v", err)
+	}
+}
+
+func TestFileSinkSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blog4go")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := path.Join(dir, "current.log")
+	sink, err := NewFileSink(name, true)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	sink.SetSymlink(true)
+	if target, _ := os.Readlink(name); path.Base(sink.currentFileName) != target {
+		t.Errorf("symlink not created. target: %s", target)
+	}
+
+	// logrotate points symlink to the new file
+	sink.SetRotatePeriod(PeriodHour)
+	if err := sink.timeRotate(sink.now()); nil != err {
+		t.Fatal(err)
+	}
+	if target, _ := os.Readlink(name); path.Base(periodFileName(name, timeCache.Now(), PeriodHour)) != target {
+		t.Errorf("symlink not updated after logrotate. target: %s", target)
+	}
+}